@@ -0,0 +1,159 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+// Affected lists the nodes affected by changes along with the reason they're affected
+type Affected []dggraph.AffectedNode
+
+// to be used in non-unit tests
+var AffectedNodes = affected
+
+// to be used in non-unit tests
+var TestsFor = testsFor
+
+// gitDiff lists the files changed on the current branch since it diverged from given base
+var gitDiff = func(base string) ([]byte, error) {
+	output, err := exec.Command("git", "diff", "--name-only", base+"...HEAD").Output()
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		return nil, fmt.Errorf("git diff failed: %s", strings.TrimSpace(string(exitError.Stderr)))
+	}
+	return output, err
+}
+
+/*
+Get the changed files: the files passed (read from stdin, one per line, if the only file is "-")
+or the files changed since given base according to git.
+*/
+func changedFiles(files []string, base string, filePathDg string, readFile ReadFileFunc) ([]string, error) {
+	var output []byte
+	var err error
+	switch {
+	case len(files) > 0 && base != "":
+		return nil, fmt.Errorf("changed files cannot be passed together with the base to compare to")
+	case base != "":
+		output, err = gitDiff(base)
+	case len(files) == 1 && files[0] == StdinFilePath:
+		if filePathDg == StdinFilePath {
+			return nil, fmt.Errorf("changed files cannot be read from stdin when the dependency graph is read from stdin")
+		}
+		output, err = readFile(StdinFilePath)
+	case len(files) > 0:
+		return files, nil
+	default:
+		return nil, fmt.Errorf("changed files must be passed or the base to compare to must be set")
+	}
+	if err != nil {
+		return nil, err
+	}
+	result := []string{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if file := strings.TrimSpace(line); file != "" {
+			result = append(result, file)
+		}
+	}
+	return result, nil
+}
+
+// loadOwners reads the file mapping path prefixes to a node or a list of nodes owning the files with the prefix
+func loadOwners(filePath string, readFile ReadFileFunc) (dggraph.Owners, error) {
+	owners := make(dggraph.Owners)
+	if filePath == "" {
+		return owners, nil
+	}
+	data, err := readFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]any
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("invalid owners file %s: %w", filePath, err)
+	}
+	for prefix, value := range mapping {
+		switch nodes := value.(type) {
+		case string:
+			owners[prefix] = []string{nodes}
+		case []any:
+			for _, node := range nodes {
+				name, isString := node.(string)
+				if !isString {
+					return nil, fmt.Errorf("invalid owners file %s: owners of %s must be strings", filePath, prefix)
+				}
+				owners[prefix] = append(owners[prefix], name)
+			}
+		default:
+			return nil, fmt.Errorf("invalid owners file %s: owners of %s must be a string or a list of strings", filePath, prefix)
+		}
+	}
+	return owners, nil
+}
+
+/*
+Get the nodes affected by changed files: files are mapped to nodes (by the node name or by the owners of the files)
+and the transitive dependents of the nodes are found in the reverse dependency graph as done for dependents.
+Files not mapped to any node are returned as well.
+*/
+func affected(filePathDg string, filePathDgReverse string, files []string, filePathOwners string,
+	readFile ReadFileFunc) (Affected, []string, error) {
+	reversed, err := loadReversedGraph(filePathDg, filePathDgReverse, readFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed, unmapped, err := changedNodes(reversed, files, filePathOwners, readFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	return Affected(dggraph.Affected(reversed, changed)), unmapped, nil
+}
+
+// changedNodes maps changed files to nodes of the graph returning the files not mapped to any node as well
+func changedNodes(graph *dggraph.Graph, files []string, filePathOwners string, readFile ReadFileFunc) ([]string, []string, error) {
+	owners, err := loadOwners(filePathOwners, readFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed := []string{}
+	unmapped := []string{}
+	for _, file := range files {
+		nodes := owners.FileNodes(graph, file)
+		if len(nodes) == 0 {
+			unmapped = append(unmapped, file)
+		}
+		changed = append(changed, nodes...)
+	}
+	return changed, unmapped, nil
+}
+
+/*
+Get the tests affected by changed nodes (given targets which can be patterns) and changed files mapped to nodes
+as done for affected nodes. Node attributes used to select tests are read from the dependency graph file.
+*/
+func testsFor(filePathDg string, targets []string, files []string, filePathOwners string,
+	selector dggraph.TestSelector, byDistance bool, readFile ReadFileFunc) (Affected, []string, error) {
+	graph, nodeAttributes, err := loadGraphWithAttributes(filePathDg, readFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	changed, err := dggraph.ExpandPatterns(graph.Nodes(), targets)
+	if err != nil {
+		return nil, nil, err
+	}
+	reversed := graph.Reversed()
+	fileNodes, unmapped, err := changedNodes(reversed, files, filePathOwners, readFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	tests, err := dggraph.TestsFor(reversed, append(changed, fileNodes...), selector, nodeAttributes, byDistance)
+	return Affected(tests), unmapped, err
+}
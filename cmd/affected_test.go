@@ -0,0 +1,160 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseAffected struct {
+	files            []string
+	owners           []byte
+	expected         Affected
+	expectedUnmapped []string
+	expectedError    string
+}
+
+func TestAffected(t *testing.T) {
+	input := []byte(`{
+		"//app:main": ["//lib:db"],
+		"//lib:db": ["//lib:log"],
+		"lib/log.py": []
+	}`)
+	cases := []testCaseAffected{
+		// files are mapped to nodes by their names
+		{
+			files:            []string{"lib/log.py", "README.md"},
+			expected:         Affected{{Node: "lib/log.py", Reason: []string{"lib/log.py"}}},
+			expectedUnmapped: []string{"README.md"},
+		},
+		// files are mapped to nodes by their owners
+		{
+			files:  []string{"lib/db/models.py", "lib/log.py"},
+			owners: []byte(`{"lib/db/": "//lib:db", "app/": ["//app:main"]}`),
+			expected: Affected{
+				{Node: "//app:main", Reason: []string{"//lib:db", "//app:main"}},
+				{Node: "//lib:db", Reason: []string{"//lib:db"}},
+				{Node: "lib/log.py", Reason: []string{"lib/log.py"}},
+			},
+			expectedUnmapped: []string{},
+		},
+		// files whose owners are not in the graph are not mapped
+		{
+			files:            []string{"lib/db/models.py", "docs/index.md"},
+			owners:           []byte(`{"lib/db/": ["//lib:db", "//lib:gone"], "docs/": "//docs:site"}`),
+			expected:         Affected{{Node: "//app:main", Reason: []string{"//lib:db", "//app:main"}}, {Node: "//lib:db", Reason: []string{"//lib:db"}}},
+			expectedUnmapped: []string{"docs/index.md"},
+		},
+		// owners must be nodes
+		{
+			files:         []string{"lib/db/models.py"},
+			owners:        []byte(`{"lib/db/": 1}`),
+			expectedError: "invalid owners file owners.json: owners of lib/db/ must be a string or a list of strings",
+		},
+	}
+	for _, testCase := range cases {
+		MockReadFile := func(filePath string) ([]byte, error) {
+			if filePath == "owners.json" {
+				return testCase.owners, nil
+			}
+			return input, nil
+		}
+		filePathOwners := ""
+		if testCase.owners != nil {
+			filePathOwners = "owners.json"
+		}
+		result, unmapped, err := affected("mock.json", "", testCase.files, filePathOwners, MockReadFile)
+		if testCase.expectedError != "" {
+			assert.EqualError(t, err, testCase.expectedError)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.expected, result)
+		assert.Equal(t, testCase.expectedUnmapped, unmapped)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return []byte("lib/db.py\n\n  app/main.py \n"), nil
+	}
+	files, err := changedFiles([]string{StdinFilePath}, "", "dg.json", MockReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/db.py", "app/main.py"}, files)
+
+	files, err = changedFiles([]string{"lib/db.py"}, "", StdinFilePath, MockReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/db.py"}, files)
+
+	_, err = changedFiles([]string{StdinFilePath}, "", StdinFilePath, MockReadFile)
+	assert.EqualError(t, err, "changed files cannot be read from stdin when the dependency graph is read from stdin")
+	_, err = changedFiles([]string{"lib/db.py"}, "main", "dg.json", MockReadFile)
+	assert.EqualError(t, err, "changed files cannot be passed together with the base to compare to")
+	_, err = changedFiles([]string{}, "", "dg.json", MockReadFile)
+	assert.EqualError(t, err, "changed files must be passed or the base to compare to must be set")
+
+	defaultGitDiff := gitDiff
+	defer func() { gitDiff = defaultGitDiff }()
+	gitDiff = func(base string) ([]byte, error) {
+		if base != "main" {
+			return nil, fmt.Errorf("unknown revision %s", base)
+		}
+		return []byte("lib/log.py\n"), nil
+	}
+	files, err = changedFiles([]string{}, "main", "dg.json", MockReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/log.py"}, files)
+	_, err = changedFiles([]string{}, "dev", "dg.json", MockReadFile)
+	assert.EqualError(t, err, "unknown revision dev")
+}
+
+func TestRenderAffected(t *testing.T) {
+	result := Affected{
+		{Node: "app.py", Reason: []string{"log.py", "db.py", "app.py"}},
+		{Node: "db.py", Reason: []string{"log.py", "db.py"}},
+		{Node: "log.py", Reason: []string{"log.py"}},
+	}
+	output, err := renderOutput(result, OutputText, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "app.py\ndb.py\nlog.py\n", string(output))
+
+	output, err = renderOutput(result, OutputCsv, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "node,reason\napp.py,log.py db.py app.py\ndb.py,log.py db.py\nlog.py,log.py\n", string(output))
+
+	graph, err := resultGraph(result)
+	assert.NoError(t, err)
+	assert.Equal(t, AdjacencyList{"app.py": {"db.py"}, "db.py": {"log.py"}, "log.py": {}}, graph)
+}
+
+func TestTestsFor(t *testing.T) {
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return pantsPeekOutput, nil
+	}
+	inputFormat = FormatPants
+	defer func() { inputFormat = "" }()
+
+	selector := dggraph.TestSelector{Attribute: AttributeTargetType, Value: "python_source"}
+	// changed nodes can be patterns and changed files are mapped to the nodes owning them
+	result, unmapped, err := testsFor("peek.json", []string{"3rdparty/**"}, []string{"src/lib/utils.py", "BUILD"},
+		"owners.json", selector, true, func(filePath string) ([]byte, error) {
+			if filePath == "owners.json" {
+				return []byte(`{"src/lib/": "src/lib/utils.py:lib"}`), nil
+			}
+			return MockReadFile(filePath)
+		})
+	assert.NoError(t, err)
+	assert.Equal(t, Affected{
+		{Node: "src/lib/utils.py:lib", Reason: []string{"src/lib/utils.py:lib"}},
+		{Node: "src/app/main.py:lib", Reason: []string{"3rdparty/python#requests", "src/app/main.py:lib"}},
+	}, result)
+	assert.Equal(t, []string{"BUILD"}, unmapped)
+
+	_, _, err = testsFor("peek.json", []string{"tests/**"}, []string{}, "", selector, false, MockReadFile)
+	assert.EqualError(t, err, `pattern "tests/**" does not match any node`)
+}
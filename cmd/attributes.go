@@ -0,0 +1,38 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+
+// to be used in non-unit tests
+var Attributes = attributes
+
+/*
+List attributes (such as target type and sources) of given targets or of all nodes
+if no targets are given. Only some input formats provide node attributes.
+*/
+func attributes(filePath string, targets []string, readFile ReadFileFunc) (NodeAttributes, error) {
+	graph, nodeAttributes, err := loadGraphWithAttributes(filePath, readFile)
+	if err != nil {
+		return nil, err
+	}
+	targets, err = dggraph.ExpandPatterns(graph.Nodes(), targets)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(NodeAttributes)
+	if len(targets) == 0 {
+		for node, attributes := range nodeAttributes {
+			result[node] = attributes
+		}
+		return result, nil
+	}
+	for _, target := range targets {
+		if attributes, exists := nodeAttributes[target]; exists {
+			result[target] = attributes
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,132 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+/*
+Baseline records the known cycles and violations of dependency rules so that only new ones fail;
+the cycles and violations are kept in the same file so that one baseline can be used by both commands
+(a missing list means nothing is recorded yet while an empty list means everything is fixed).
+*/
+type Baseline struct {
+	Cycles     [][]string          `json:"cycles,omitzero"`
+	Violations []dggraph.Violation `json:"violations,omitzero"`
+}
+
+// baselineOptions sets the baseline file and whether it's updated
+type baselineOptions struct {
+	filePath string
+	update   bool
+}
+
+// loadBaseline reads a baseline file which may only be missing when the baseline is updated (and so created)
+func loadBaseline(options baselineOptions, readFile ReadFileFunc) (Baseline, error) {
+	var baseline Baseline
+	data, err := readFile(options.filePath)
+	if errors.Is(err, fs.ErrNotExist) && options.update {
+		return baseline, nil
+	}
+	if err != nil {
+		return baseline, err
+	}
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return baseline, fmt.Errorf("invalid baseline file %s: %w", options.filePath, err)
+	}
+	return baseline, nil
+}
+
+func writeBaseline(filePath string, baseline Baseline) error {
+	data, err := json.MarshalIndent(baseline, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, append(data, '\n'), 0o644)
+}
+
+/*
+Compare current items to the known items of the baseline: the items not in the baseline are new and the others
+are kept in the baseline, so the baseline can only shrink as the items are fixed. If nothing is recorded yet
+(the known items are nil), all current items are recorded when the baseline is updated and are new otherwise.
+*/
+func ratchet[T any](current []T, known []T, update bool, key func(item T) string) (added []T, kept []T) {
+	added, kept = []T{}, []T{}
+	if known == nil && update {
+		return added, append(kept, current...)
+	}
+	knownKeys := make(map[string]bool, len(known))
+	for _, item := range known {
+		knownKeys[key(item)] = true
+	}
+	for _, item := range current {
+		if knownKeys[key(item)] {
+			kept = append(kept, item)
+		} else {
+			added = append(added, item)
+		}
+	}
+	return added, kept
+}
+
+// cycleKey identifies a cycle which always starts from its smallest node
+func cycleKey(cycle []string) string {
+	return strings.Join(cycle, "\x00")
+}
+
+// violationKey identifies a violation by the rule and the dependency or node (the message may change, e.g. a fan-out)
+func violationKey(violation dggraph.Violation) string {
+	return strings.Join([]string{violation.Rule, violation.From, violation.To}, "\x00")
+}
+
+/*
+Get the cycles not in the baseline updating the baseline with the cycles still found if requested;
+as cycles not found cannot be told apart from fixed ones, the search of cycles must be complete
+and not limited to some of the cycles (by their length or nodes) to update the baseline.
+*/
+func cyclesBaseline(found [][]string, complete bool, cycleOptions dggraph.CycleOptions, options baselineOptions,
+	readFile ReadFileFunc) (Cycles, error) {
+	if options.update && (len(cycleOptions.Through) > 0 || cycleOptions.MaxLength > 0) {
+		return nil, fmt.Errorf("the baseline cannot be updated when the search of cycles is limited by --through or --max-length")
+	}
+	baseline, err := loadBaseline(options, readFile)
+	if err != nil {
+		return nil, err
+	}
+	added, kept := ratchet(found, baseline.Cycles, options.update, cycleKey)
+	if options.update {
+		if !complete {
+			return nil, fmt.Errorf("the baseline cannot be updated as the search of cycles was stopped")
+		}
+		baseline.Cycles = kept
+		if err := writeBaseline(options.filePath, baseline); err != nil {
+			return nil, err
+		}
+	}
+	return Cycles(added), nil
+}
+
+// violationsBaseline gets the violations not in the baseline updating the baseline with the violations still found if requested
+func violationsBaseline(found Violations, options baselineOptions, readFile ReadFileFunc) (Violations, error) {
+	baseline, err := loadBaseline(options, readFile)
+	if err != nil {
+		return nil, err
+	}
+	added, kept := ratchet(found, baseline.Violations, options.update, violationKey)
+	if options.update {
+		baseline.Violations = kept
+		if err := writeBaseline(options.filePath, baseline); err != nil {
+			return nil, err
+		}
+	}
+	return Violations(added), nil
+}
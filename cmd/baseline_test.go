@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCyclesBaseline(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "baseline.json")
+	options := baselineOptions{filePath: filePath}
+
+	// the baseline must exist unless it's created
+	_, err := cyclesBaseline([][]string{{"a", "b"}}, true, dggraph.CycleOptions{}, options, DefaultReadFile)
+	assert.ErrorContains(t, err, "no such file or directory")
+
+	// the baseline is created with the current cycles
+	options.update = true
+	added, err := cyclesBaseline([][]string{{"a", "b"}, {"c", "d"}}, true, dggraph.CycleOptions{}, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Cycles{}, added)
+
+	// new cycles are reported but not recorded while fixed cycles are removed
+	added, err = cyclesBaseline([][]string{{"a", "b"}, {"e", "f"}}, true, dggraph.CycleOptions{}, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Cycles{{"e", "f"}}, added)
+	data, _ := os.ReadFile(filePath)
+	assert.JSONEq(t, `{"cycles": [["a", "b"]]}`, string(data))
+
+	options.update = false
+	added, err = cyclesBaseline([][]string{{"a", "b"}, {"e", "f"}}, true, dggraph.CycleOptions{}, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Cycles{{"e", "f"}}, added)
+
+	// cycles not found may not be fixed
+	options.update = true
+	_, err = cyclesBaseline([][]string{}, false, dggraph.CycleOptions{}, options, DefaultReadFile)
+	assert.EqualError(t, err, "the baseline cannot be updated as the search of cycles was stopped")
+
+	// cycles not going through given nodes or longer than the maximum length may not be fixed either
+	os.WriteFile(filePath, []byte(`{"cycles": [["a", "b"], ["c", "d"]]}`), 0644)
+	for _, cycleOptions := range []dggraph.CycleOptions{{Through: []string{"a"}}, {MaxLength: 2}} {
+		_, err = cyclesBaseline([][]string{{"a", "b"}}, true, cycleOptions, options, DefaultReadFile)
+		assert.EqualError(t, err, "the baseline cannot be updated when the search of cycles is limited by --through or --max-length")
+		data, _ = os.ReadFile(filePath)
+		assert.JSONEq(t, `{"cycles": [["a", "b"], ["c", "d"]]}`, string(data))
+	}
+
+	// the limited search can still be compared to the baseline
+	options.update = false
+	added, err = cyclesBaseline([][]string{{"a", "b"}}, true, dggraph.CycleOptions{Through: []string{"a"}}, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Cycles{}, added)
+}
+
+func TestViolationsBaseline(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "baseline.json")
+	os.WriteFile(filePath, []byte(`{"cycles": []}`), 0644)
+	options := baselineOptions{filePath: filePath}
+	violations := Violations{
+		{Rule: "forbidden lib -> app", From: "lib/db.py", To: "app/main.py", Message: "dependency is forbidden"},
+		{Rule: "max-fan-out 1", From: "lib/db.py", Message: "fan-out of 2 exceeds 1"},
+	}
+
+	// no violations are recorded in the baseline yet, so they're all new
+	added, err := violationsBaseline(violations, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, violations, added)
+
+	// violations are recorded along with the cycles
+	options.update = true
+	added, err = violationsBaseline(violations, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Violations{}, added)
+	data, _ := os.ReadFile(filePath)
+	assert.Contains(t, string(data), `"cycles": []`)
+
+	// violations are matched regardless of their messages
+	options.update = false
+	changed := Violations{{Rule: "max-fan-out 1", From: "lib/db.py", Message: "fan-out of 3 exceeds 1"}}
+	added, err = violationsBaseline(changed, options, DefaultReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Violations{}, added)
+
+	options.update = true
+	_, err = violationsBaseline(changed, options, DefaultReadFile)
+	assert.NoError(t, err)
+	data, _ = os.ReadFile(filePath)
+	assert.Contains(t, string(data), "fan-out of 3 exceeds 1")
+	assert.NotContains(t, string(data), "forbidden")
+}
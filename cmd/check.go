@@ -0,0 +1,30 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+
+// Violations are dependencies and nodes violating dependency rules
+type Violations []dggraph.Violation
+
+// to be used in non-unit tests
+var Check = check
+
+// check checks the dependency graph against the rules of given rules file
+func check(filePath string, filePathRules string, readFile ReadFileFunc) (Violations, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, err
+	}
+	data, err := readFile(filePathRules)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := dggraph.ParseRules(data)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := dggraph.Check(graph, rules)
+	return Violations(violations), err
+}
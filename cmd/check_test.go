@@ -0,0 +1,48 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	files := map[string][]byte{
+		"dg.json": []byte(`{"lib/db.py": ["app/main.py"], "app/main.py": ["lib/db.py"]}`),
+		"rules.json": []byte(`{
+			"layers": {"app": ["app/**"], "lib": ["lib/**"]},
+			"rules": [
+				{"type": "forbidden", "from": ["lib"], "to": ["app"]},
+				{"name": "no fan-out", "type": "max-fan-out", "max": 0}
+			]
+		}`),
+	}
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return files[filePath], nil
+	}
+	violations, err := check("dg.json", "rules.json", MockReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, Violations{
+		{Rule: "forbidden lib -> app", From: "lib/db.py", To: "app/main.py", Message: "dependency is forbidden"},
+		{Rule: "no fan-out", From: "app/main.py", Message: "fan-out of 1 exceeds 0"},
+		{Rule: "no fan-out", From: "lib/db.py", Message: "fan-out of 1 exceeds 0"},
+	}, violations)
+
+	output, err := renderOutput(violations, OutputText, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `forbidden lib -> app: lib/db.py -> app/main.py: dependency is forbidden
+no fan-out: app/main.py: fan-out of 1 exceeds 0
+no fan-out: lib/db.py: fan-out of 1 exceeds 0
+`, string(output))
+
+	output, err = renderOutput(violations[:1], OutputCsv, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "rule,from,to,message\nforbidden lib -> app,lib/db.py,app/main.py,dependency is forbidden\n", string(output))
+
+	files["rules.json"] = []byte(`{"rules": [{"type": "forbidden", "from": ["lib"], "to": ["app"]}]}`)
+	_, err = check("dg.json", "rules.json", MockReadFile)
+	assert.EqualError(t, err, `rule 1 refers to undefined layer "lib"`)
+}
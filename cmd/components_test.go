@@ -171,3 +171,76 @@ func TestGetConnectedComponents(t *testing.T) {
 		assert.Equal(t, testCase.expected, components)
 	}
 }
+
+type TestCaseStrongComponents struct {
+	input    []byte
+	minSize  int
+	expected StrongComponents
+}
+
+func TestGetStrongComponents(t *testing.T) {
+	cases := []TestCaseStrongComponents{
+		// every node that is not part of a cycle is a component on its own
+		{
+			input: []byte(`
+			{
+				"foo": ["bar"],
+				"bar": ["baz"],
+				"baz": ["foo", "spam"],
+				"spam": ["eggs"],
+				"eggs": ["spam"]
+			}
+			`),
+			minSize: 1,
+			expected: StrongComponents{
+				{Size: 3, Nodes: []string{"bar", "baz", "foo"}},
+				{Size: 2, Nodes: []string{"eggs", "spam"}},
+			},
+		},
+		// weakly connected nodes are not strongly connected
+		{
+			input: []byte(`
+			{
+				"foo": ["bar"],
+				"bar": ["baz"],
+				"cheese": ["cheese"]
+			}
+			`),
+			minSize: 1,
+			expected: StrongComponents{
+				{Size: 1, Nodes: []string{"bar"}},
+				{Size: 1, Nodes: []string{"baz"}},
+				{Size: 1, Nodes: []string{"cheese"}},
+				{Size: 1, Nodes: []string{"foo"}},
+			},
+		},
+		// only components of at least given size
+		{
+			input: []byte(`
+			{
+				"foo": ["bar"],
+				"bar": ["foo", "baz"]
+			}
+			`),
+			minSize:  2,
+			expected: StrongComponents{{Size: 2, Nodes: []string{"bar", "foo"}}},
+		},
+		// empty graph
+		{
+			input:    []byte(`{}`),
+			minSize:  1,
+			expected: StrongComponents{},
+		},
+	}
+
+	for _, testCase := range cases {
+		MockReadFile := func(filePath string) ([]byte, error) {
+			return testCase.input, nil
+		}
+		components, err := listStrongComponents("mock.json", testCase.minSize, MockReadFile)
+		if err != nil {
+			t.Fail()
+		}
+		assert.Equal(t, testCase.expected, components)
+	}
+}
@@ -0,0 +1,21 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+
+// to be used in non-unit tests
+var Condense = condense
+
+/*
+Condense the dependency graph into the graph of its strongly connected components where every
+component is a single node; the result is acyclic and can be used as input to other commands.
+*/
+func condense(filePath string, readFile ReadFileFunc) (AdjacencyList, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, err
+	}
+	return dggraph.Condense(graph), nil
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseCondense struct {
+	input    []byte
+	expected AdjacencyList
+}
+
+func TestCondense(t *testing.T) {
+	cases := []testCaseCondense{
+		// cycles become single nodes
+		{
+			input: []byte(`
+			{
+				"app.py": ["foo.py", "log.py"],
+				"foo.py": ["bar.py"],
+				"bar.py": ["foo.py", "log.py"],
+				"log.py": ["log.py"]
+			}
+			`),
+			expected: AdjacencyList{
+				"app.py":        {"bar.py|foo.py", "log.py"},
+				"bar.py|foo.py": {"log.py"},
+				"log.py":        {},
+			},
+		},
+		// acyclic graph is kept as is
+		{
+			input: []byte(`
+			{
+				"foo.py": ["bar.py"],
+				"bar.py": ["baz.py"]
+			}
+			`),
+			expected: AdjacencyList{
+				"foo.py": {"bar.py"},
+				"bar.py": {"baz.py"},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		MockReadFile := func(filePath string) ([]byte, error) {
+			return testCase.input, nil
+		}
+		result, err := condense("mock.json", MockReadFile)
+		if err != nil {
+			t.Fail()
+		}
+		assert.Equal(t, testCase.expected, result)
+	}
+}
@@ -3,101 +3,38 @@ Copyright © 2024 Alexey Tereshenkov
 */
 package cmd
 
-import "sort"
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
 
-func cycles(filePath string, readFile ReadFileFunc) ([][]string, error) {
-	jsonData, err := readFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-	adjacencyList, err := loadJsonFile(jsonData)
-	if err != nil {
-		return nil, err
-	}
+// warning reported when the search of cycles was stopped by a limit before all cycles were found
+const incompleteCyclesWarning = "the search of cycles was stopped by --max-cycles or --timeout, not all cycles are listed"
 
-	recursionStack := make(map[string]bool)
-	cycles := make(map[string][]string)
-
-	// Sort a cycle starting from a specific node
-	sortCycle := func(cycle []string) []string {
-		// Find the minimum element
-		minIdx := 0
-		for i := 1; i < len(cycle); i++ {
-			if cycle[i] < cycle[minIdx] {
-				minIdx = i
-			}
-		}
-
-		// Rotate the slice to place the minimum element at the start
-		result := make([]string, len(cycle))
-		for i := 0; i < len(cycle); i++ {
-			result[i] = cycle[(i+minIdx)%len(cycle)]
-		}
-		return result
-	}
-
-	// Create a unique key for given cycle
-	cycleKey := func(cycle []string) string {
-		sortedCycle := sortCycle(cycle)
-		result := ""
-		for _, node := range sortedCycle {
-			result += node
-		}
-		return result
+// find cycles reporting whether all cycles matching the options were found
+func cycles(filePath string, options dggraph.CycleOptions, readFile ReadFileFunc) ([][]string, bool, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, false, err
 	}
+	return dggraph.Cycles(graph, options)
+}
 
-	var dfs func(node string, path []string)
-	dfs = func(node string, path []string) {
-		recursionStack[node] = true
-		path = append(path, node)
-
-		for _, neighbor := range adjacencyList[node] {
-			// Found a cycle
-			if recursionStack[neighbor] {
-				// Find the start of the cycle in the path
-				cycleStart := -1
-				for i, n := range path {
-					if n == neighbor {
-						cycleStart = i
-						break
-					}
-				}
-
-				if cycleStart != -1 {
-					cycle := path[cycleStart:]
-					sortedCycle := sortCycle(cycle)
-					// Use the sorted cycle string as key to avoid duplicates
-					key := cycleKey(cycle)
-					cycles[key] = sortedCycle
-				}
-			}
-
-			// Continue exploring if the neighbor isn't in the current path
-			// because there may be overlapping cycles (A->B->C->A & B->C->D->A)
-			if !recursionStack[neighbor] {
-				dfs(neighbor, path)
-			}
-		}
-
-		recursionStack[node] = false
-	}
+// CycleBreaks are edges suggested to be removed to make the dependency graph acyclic
+type CycleBreaks []dggraph.CycleBreak
 
-	for node := range adjacencyList {
-		if !recursionStack[node] {
-			dfs(node, []string{})
-		}
+// suggest edges to remove to make the graph acyclic reporting whether all cycles were found to rank the edges
+func suggestBreaks(filePath string, options dggraph.CycleOptions, readFile ReadFileFunc) (CycleBreaks, bool, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, false, err
 	}
+	breaks, complete, err := dggraph.SuggestBreaks(graph, options)
+	return CycleBreaks(breaks), complete, err
+}
 
-	// Convert cycles map to slice and sort for consistent output
-	result := make([][]string, 0, len(cycles))
-	for _, cycle := range cycles {
-		result = append(result, cycle)
+// get the graph without the edges suggested to be removed to make it acyclic
+func breakCycles(filePath string, readFile ReadFileFunc) (AdjacencyList, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, err
 	}
-
-	// Sort the cycles based on their first element for consistent output
-	sort.Slice(result, func(i, j int) bool {
-		return result[i][0] < result[j][0]
-	})
-
-	return result, nil
+	return dggraph.RemoveEdges(graph, dggraph.FeedbackArcSet(graph)), nil
 }
@@ -6,6 +6,8 @@ package cmd
 import (
 	"testing"
 
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -155,18 +157,57 @@ func TestFindCycles(t *testing.T) {
 				{"B", "C", "D"}, {"A", "B", "C", "D", "E"},
 			},
 		},
+		// cycles with the same nodes concatenated are different cycles
+		{
+			input: []byte(`{
+				"ab": ["c"],
+				"c": ["ab"],
+				"a": ["bc"],
+				"bc": ["a"]
+			}`),
+			expected: [][]string{
+				{"a", "bc"}, {"ab", "c"},
+			},
+		},
 	}
 
 	for _, testCase := range cases {
 		MockReadFile := func(filePath string) ([]byte, error) {
 			return testCase.input, nil
 		}
-		result, err := cycles("mock-dg.json", MockReadFile)
+		result, complete, err := cycles("mock-dg.json", dggraph.CycleOptions{}, MockReadFile)
 		if err != nil {
 			t.Fail()
 		}
 		// the order of cycles may change depending on the implementation of the DFS,
 		// but the order the cycles are returned in shouldn't really matter
 		assert.ElementsMatch(t, testCase.expected, result)
+		assert.True(t, complete)
+	}
+}
+
+func TestSuggestBreaks(t *testing.T) {
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return []byte(`{
+			"A": ["B"],
+			"B": ["A", "C"],
+			"C": ["A"],
+			"D": ["D"]
+		}`), nil
 	}
+	breaks, complete, err := suggestBreaks("mock-dg.json", dggraph.CycleOptions{}, MockReadFile)
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, CycleBreaks{
+		{Edge: dggraph.Edge{From: "A", To: "B"}, Cycles: 2},
+		{Edge: dggraph.Edge{From: "D", To: "D"}, Cycles: 1},
+	}, breaks)
+
+	output, err := renderOutput(breaks, OutputCsv, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "from,to,cycles\nA,B,2\nD,D,1\n", string(output))
+
+	acyclic, err := breakCycles("mock-dg.json", MockReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, AdjacencyList{"A": {}, "B": {"A", "C"}, "C": {"A"}, "D": {}}, acyclic)
 }
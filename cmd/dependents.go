@@ -3,26 +3,7 @@ Copyright © 2024 Alexey Tereshenkov
 */
 package cmd
 
-import (
-	"slices"
-)
-
-/*
-Reverse adjacency lists:
-{"foo": ["bar", "baz"]} ->
-{"bar": ["foo"], "baz": ["foo"]
-}
-*/
-func reverseAdjacencyLists(adjacencyList AdjacencyList) AdjacencyList {
-	reversed := make(AdjacencyList)
-	for node, dependencies := range adjacencyList {
-		for _, dep := range dependencies {
-			reversed[dep] = append(reversed[dep], node)
-			slices.Sort(reversed[dep])
-		}
-	}
-	return reversed
-}
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
 
 // to be used in non-unit tests
 var Dependents = dependents
@@ -33,41 +14,21 @@ it's used, otherwise a dependency graph is reversed first.
 */
 func dependents(filePathDg string, filePathDgReverse string, targets []string, transitive bool, reflexive bool,
 	depth int, DefaultReadFile ReadFileFunc) ([]string, error) {
-	var adjacencyList AdjacencyList
-
-	if filePathDgReverse != "" {
-		jsonData, err := DefaultReadFile(filePathDgReverse)
-		if err != nil {
-			return nil, err
-		}
-		adjacencyList, err = loadJsonFile(jsonData)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		jsonData, err := DefaultReadFile(filePathDg)
-		if err != nil {
-			return nil, err
-		}
-		adjacencyList, err = loadJsonFile(jsonData)
-		if err != nil {
-			return nil, err
-		}
-		adjacencyList = reverseAdjacencyLists(adjacencyList)
+	graph, err := loadReversedGraph(filePathDg, filePathDgReverse, DefaultReadFile)
+	if err != nil {
+		return nil, err
 	}
+	return dggraph.Dependencies(graph, targets, dggraph.DependencyOptions{Transitive: transitive, Reflexive: reflexive, Depth: depth})
+}
 
-	var rdeps []string
-	if transitive {
-		rdeps = getDepsTransitive(adjacencyList, targets, depth)
-	} else {
-		rdeps = getDepsDirect(adjacencyList, targets)
+// loadReversedGraph loads the reverse dependency graph if it's provided and reverses the dependency graph otherwise
+func loadReversedGraph(filePathDg string, filePathDgReverse string, readFile ReadFileFunc) (*dggraph.Graph, error) {
+	if filePathDgReverse != "" {
+		return loadGraph(filePathDgReverse, readFile)
 	}
-
-	if reflexive {
-		reflexiveTargets := getReflexiveTargets(targets, adjacencyList)
-		rdeps = append(rdeps, reflexiveTargets...)
+	dg, err := loadGraph(filePathDg, readFile)
+	if err != nil {
+		return nil, err
 	}
-	slices.Sort(rdeps)
-	rdeps = slices.Compact(rdeps)
-	return rdeps, nil
+	return dg.Reversed(), nil
 }
@@ -4,28 +4,100 @@ Copyright © 2024 Alexey Tereshenkov
 package cmd
 
 import (
-	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"strings"
+	"sync"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
 )
 
 // Function type to be used for reading files
 type ReadFileFunc func(filePath string) ([]byte, error)
 
-type AdjacencyList map[string][]string
+// AdjacencyList maps every node to its direct dependencies; aliased so that results of the library can be used as is
+type AdjacencyList = dggraph.AdjacencyList
+
+// NodeAttributes maps nodes to their attributes such as target type or sources
+type NodeAttributes = dggraph.NodeAttributes
+
+const (
+	FormatJson      = dggraph.FormatJson
+	FormatJsonLines = dggraph.FormatJsonLines
+	FormatDot       = dggraph.FormatDot
+	FormatCsv       = dggraph.FormatCsv
+	FormatTsv       = dggraph.FormatTsv
+	FormatGraphml   = dggraph.FormatGraphml
+	FormatPants     = dggraph.FormatPants
+
+	FormatBazelGraph     = dggraph.FormatBazelGraph
+	FormatBazelProto     = dggraph.FormatBazelProto
+	FormatBazelJsonProto = dggraph.FormatBazelJsonProto
+	FormatBazelXml       = dggraph.FormatBazelXml
+
+	AttributeKind       = dggraph.AttributeKind
+	AttributeTargetType = dggraph.AttributeTargetType
+	AttributeSources    = dggraph.AttributeSources
+)
+
+// file path to be passed to read the dependency graph from the standard input
+const StdinFilePath = "-"
+
+// newStdinReader reads the whole input once; the standard input can be consumed only once,
+// but some commands read the dependency graph twice
+func newStdinReader(input io.Reader) func() ([]byte, error) {
+	return sync.OnceValues(func() ([]byte, error) {
+		return io.ReadAll(input)
+	})
+}
+
+var readStdin = newStdinReader(os.Stdin)
 
 var DefaultReadFile = func(filePath string) ([]byte, error) {
-	jsonData, readingFileError := os.ReadFile(filePath)
+	var jsonData []byte
+	var readingFileError error
+	if filePath == StdinFilePath {
+		jsonData, readingFileError = readStdin()
+	} else {
+		jsonData, readingFileError = os.ReadFile(filePath)
+	}
 	if readingFileError != nil {
 		return nil, readingFileError
 	}
-	return jsonData, nil
+	return dggraph.Decompress(jsonData)
 }
 
-func loadJsonFile(jsonData []byte) (AdjacencyList, error) {
-	var adjacencyList AdjacencyList
-	loadingJsonError := json.Unmarshal(jsonData, &adjacencyList)
-	if loadingJsonError != nil {
-		return nil, loadingJsonError
+/*
+Get the input format for given file: the format set with the `--format` flag
+takes precedence, then the file extension is used (ignoring the extension of compressed
+files such as `dg.dot.gz`), and JSON is assumed otherwise (e.g. for the standard input).
+*/
+func detectFormat(filePath string) (string, error) {
+	if inputFormat != "" {
+		if !dggraph.IsValidFormat(inputFormat) {
+			return "", fmt.Errorf("invalid format: %s. Allowed formats are: %s", inputFormat, strings.Join(dggraph.Formats, ","))
+		}
+		return inputFormat, nil
+	}
+	return dggraph.FormatFromPath(filePath), nil
+}
+
+// loadAdjacencyList parses file contents with the loader matching the file format
+func loadAdjacencyList(filePath string, data []byte) (AdjacencyList, error) {
+	adjacencyList, _, err := loadAdjacencyListWithAttributes(filePath, data)
+	return adjacencyList, err
+}
+
+// loadAdjacencyListWithAttributes parses file contents keeping node attributes if the format provides them
+func loadAdjacencyListWithAttributes(filePath string, data []byte) (AdjacencyList, NodeAttributes, error) {
+	format, err := detectFormat(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	adjacencyList, attributes, err := dggraph.ParseAdjacencyList(data, format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading %s as %s: %w", filePath, format, err)
 	}
-	return adjacencyList, nil
+	return adjacencyList, attributes, nil
 }
@@ -0,0 +1,106 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := map[string]string{
+		"dg.json":            FormatJson,
+		"dg.json.gz":         FormatJson,
+		"dg.DOT":             FormatDot,
+		"dg.graphml.zst":     FormatGraphml,
+		"edges.tsv.zstd":     FormatTsv,
+		"dg.ndjson":          FormatJsonLines,
+		StdinFilePath:        FormatJson,
+		"dg-without-ext":     FormatJson,
+		"archive.tar.gz.csv": FormatCsv,
+	}
+	for filePath, expected := range cases {
+		format, err := detectFormat(filePath)
+		if err != nil {
+			t.Fail()
+		}
+		assert.Equal(t, expected, format, filePath)
+	}
+}
+
+func TestDefaultReadFileCompressed(t *testing.T) {
+	data := []byte(`{"foo.py": ["bar.py"]}`)
+	directory := t.TempDir()
+
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	writer.Write(data)
+	writer.Close()
+
+	encoder, _ := zstd.NewWriter(nil)
+	zstdCompressed := encoder.EncodeAll(data, nil)
+	encoder.Close()
+
+	files := map[string][]byte{
+		"dg.json":     data,
+		"dg.json.gz":  gzipped.Bytes(),
+		"dg.json.zst": zstdCompressed,
+	}
+	for fileName, contents := range files {
+		filePath := filepath.Join(directory, fileName)
+		os.WriteFile(filePath, contents, 0644)
+
+		result, err := DefaultReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, data, result, fileName)
+	}
+
+	_, err := DefaultReadFile(filepath.Join(directory, "missing.json"))
+	assert.Error(t, err)
+
+	// truncated gzip data
+	truncated := filepath.Join(directory, "truncated.json.gz")
+	os.WriteFile(truncated, gzipped.Bytes()[:2], 0644)
+	_, err = DefaultReadFile(truncated)
+	assert.Error(t, err)
+}
+
+func TestDefaultReadFileStdin(t *testing.T) {
+	data := []byte(`{"foo.py": ["bar.py"]}`)
+	var gzipped bytes.Buffer
+	writer := gzip.NewWriter(&gzipped)
+	writer.Write(data)
+	writer.Close()
+
+	defaultReadStdin := readStdin
+	defer func() { readStdin = defaultReadStdin }()
+	for name, input := range map[string][]byte{"plain": data, "gzip": gzipped.Bytes()} {
+		readStdin = newStdinReader(bytes.NewReader(input))
+		result, err := DefaultReadFile(StdinFilePath)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		assert.Equal(t, data, result, name)
+
+		// the dependency graph can be read again once the input is consumed
+		graph, err := loadGraph(StdinFilePath, DefaultReadFile)
+		if err != nil {
+			t.Fatal(name, err)
+		}
+		assert.Equal(t, []string{"bar.py", "foo.py"}, graph.Nodes(), name)
+	}
+
+	readStdin = newStdinReader(strings.NewReader(`{"foo.py": `))
+	_, err := loadGraph(StdinFilePath, DefaultReadFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+// GraphDiff lists changes between two snapshots of a dependency graph
+type GraphDiff = dggraph.GraphDiff
+
+// to be used in non-unit tests
+var Diff = diff
+
+// diff compares two snapshots of a dependency graph
+func diff(filePathOld string, filePathNew string, options dggraph.DiffOptions, readFile ReadFileFunc) (GraphDiff, error) {
+	if filePathOld == "" || filePathNew == "" {
+		return GraphDiff{}, fmt.Errorf("both the old and the new dependency graph files must be set")
+	}
+	oldGraph, err := loadGraph(filePathOld, readFile)
+	if err != nil {
+		return GraphDiff{}, err
+	}
+	newGraph, err := loadGraph(filePathNew, readFile)
+	if err != nil {
+		return GraphDiff{}, err
+	}
+	return dggraph.Diff(oldGraph, newGraph, options)
+}
+
+// formatComponents formats components as space-separated nodes in brackets
+func formatComponents(components [][]string) string {
+	formatted := make([]string, len(components))
+	for i, component := range components {
+		formatted[i] = "[" + formatValue(component) + "]"
+	}
+	return strings.Join(formatted, " ")
+}
+
+// diffRows flattens the changes into rows of the kind of every change and the change itself
+func diffRows(value GraphDiff) (rows [][]string, records []any) {
+	add := func(change string, text string, record any) {
+		rows = append(rows, []string{change, text})
+		records = append(records, map[string]any{"change": change, "value": record})
+	}
+	for _, node := range value.AddedNodes {
+		add("added-node", node, node)
+	}
+	for _, node := range value.RemovedNodes {
+		add("removed-node", node, node)
+	}
+	for _, edge := range value.AddedEdges {
+		add("added-edge", edge.From+" -> "+edge.To, edge)
+	}
+	for _, edge := range value.RemovedEdges {
+		add("removed-edge", edge.From+" -> "+edge.To, edge)
+	}
+	for _, change := range value.DependencyCountChanges {
+		add("dependency-count", fmt.Sprintf("%s %d -> %d (%+d)", change.Node, change.Old, change.New, change.New-change.Old), change)
+	}
+	for _, cycle := range value.NewCycles {
+		add("new-cycle", formatValue(cycle), cycle)
+	}
+	for _, change := range value.MergedComponents {
+		add("merged-components", formatComponents(change.Old)+" -> "+formatComponents(change.New), change)
+	}
+	for _, change := range value.SplitComponents {
+		add("split-component", formatComponents(change.Old)+" -> "+formatComponents(change.New), change)
+	}
+	return rows, records
+}
+
+// renderDiff renders the changes as text grouped in sections by the kind of change
+func renderDiff(value GraphDiff) []byte {
+	var output bytes.Buffer
+	rows, _ := diffRows(value)
+	sections := map[string]string{
+		"added-node":        "Added nodes",
+		"removed-node":      "Removed nodes",
+		"added-edge":        "Added edges",
+		"removed-edge":      "Removed edges",
+		"dependency-count":  "Largest changes of transitive dependency counts",
+		"new-cycle":         "New cycles",
+		"merged-components": "Merged components",
+		"split-component":   "Split components",
+	}
+	previous := ""
+	for _, row := range rows {
+		if row[0] != previous {
+			if previous != "" {
+				output.WriteString("\n")
+			}
+			fmt.Fprintf(&output, "%s:\n", sections[row[0]])
+			previous = row[0]
+		}
+		fmt.Fprintf(&output, "  %s\n", row[1])
+	}
+	if len(rows) == 0 {
+		output.WriteString("No changes\n")
+	}
+	return output.Bytes()
+}
@@ -0,0 +1,60 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	snapshots := map[string][]byte{
+		"old.json": []byte(`{"app.py": ["db.py"], "db.py": [], "cli.py": ["log.py"]}`),
+		"new.json": []byte(`{"app.py": ["db.py", "cli.py"], "db.py": ["app.py"], "cli.py": []}`),
+	}
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return snapshots[filePath], nil
+	}
+	result, err := diff("old.json", "new.json", dggraph.DiffOptions{}, MockReadFile)
+	assert.NoError(t, err)
+
+	output, err := renderOutput(result, OutputText, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `Removed nodes:
+  log.py
+
+Added edges:
+  app.py -> cli.py
+  db.py -> app.py
+
+Removed edges:
+  cli.py -> log.py
+
+Largest changes of transitive dependency counts:
+  db.py 0 -> 3 (+3)
+  app.py 1 -> 3 (+2)
+  cli.py 1 -> 0 (-1)
+
+New cycles:
+  app.py db.py
+
+Merged components:
+  [app.py db.py] [cli.py log.py] -> [app.py cli.py db.py]
+`, string(output))
+
+	output, err = renderOutput(result, OutputCsv, renderOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, string(output), "change,value\nremoved-node,log.py\nadded-edge,app.py -> cli.py\n")
+
+	result, err = diff("old.json", "old.json", dggraph.DiffOptions{}, MockReadFile)
+	assert.NoError(t, err)
+	output, err = renderOutput(result, OutputText, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "No changes\n", string(output))
+
+	_, err = diff("old.json", "", dggraph.DiffOptions{}, MockReadFile)
+	assert.EqualError(t, err, "both the old and the new dependency graph files must be set")
+}
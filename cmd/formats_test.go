@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseFormats struct {
+	filePath string
+	input    []byte
+	expected AdjacencyList
+}
+
+func TestLoadFormats(t *testing.T) {
+	cases := []testCaseFormats{
+		// JSON Lines with node and edge records
+		{
+			filePath: "mock.jsonl",
+			input: []byte(`
+			{"node": "foo.py", "dependencies": ["bar.py", "baz.py"]}
+
+			{"source": "bar.py", "target": "baz.py"}
+			{"node": "spam.py"}
+			`),
+			expected: AdjacencyList{
+				"foo.py":  {"bar.py", "baz.py"},
+				"bar.py":  {"baz.py"},
+				"spam.py": {},
+			},
+		},
+		// CSV with a header, a duplicate edge, and a node without dependencies
+		{
+			filePath: "mock.csv",
+			input: []byte(`source,target
+foo.py,bar.py
+foo.py,baz.py
+foo.py,bar.py
+# comments are skipped
+spam.py
+`),
+			expected: AdjacencyList{
+				"foo.py":  {"bar.py", "baz.py"},
+				"spam.py": {},
+			},
+		},
+		// TSV without a header
+		{
+			filePath: "mock.tsv",
+			input:    []byte("foo.py\tbar.py\nbar.py\tbaz.py\n"),
+			expected: AdjacencyList{
+				"foo.py": {"bar.py"},
+				"bar.py": {"baz.py"},
+			},
+		},
+		// GraphML declaring all nodes
+		{
+			filePath: "mock.graphml",
+			input: []byte(`<?xml version="1.0" encoding="UTF-8"?>
+			<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+				<graph id="G" edgedefault="directed">
+					<node id="foo.py"/>
+					<node id="bar.py"/>
+					<node id="baz.py"/>
+					<edge source="foo.py" target="bar.py"/>
+					<edge source="bar.py" target="baz.py"/>
+				</graph>
+			</graphml>`),
+			expected: AdjacencyList{
+				"foo.py": {"bar.py"},
+				"bar.py": {"baz.py"},
+				"baz.py": {},
+			},
+		},
+		// DOT with attributes, comments, edge chains, and subgraphs
+		{
+			filePath: "mock.dot",
+			input: []byte(`
+			digraph "dg" {
+				// global attributes
+				rankdir=LR;
+				node [shape=box];
+				"foo.py" -> "bar.py" -> baz [color="red"];
+				/* edges to every node of a subgraph */
+				spam -> {eggs; "ham"}
+				"with \"quotes\""
+			}
+			`),
+			expected: AdjacencyList{
+				"foo.py":        {"bar.py"},
+				"bar.py":        {"baz"},
+				"baz":           {},
+				"spam":          {"eggs", "ham"},
+				"eggs":          {},
+				"ham":           {},
+				`with "quotes"`: {},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		result, err := loadAdjacencyList(testCase.filePath, testCase.input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, result, testCase.filePath)
+	}
+}
+
+func TestLoadFormatsFlag(t *testing.T) {
+	inputFormat = FormatCsv
+	defer func() { inputFormat = "" }()
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return []byte("foo.py,bar.py\nbar.py,baz.py\n"), nil
+	}
+	result, err := dependencies("mock.txt", []string{"foo.py"}, true, false, 0, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, []string{"bar.py", "baz.py"}, result)
+
+	inputFormat = "yaml"
+	_, err = dependencies("mock.txt", []string{"foo.py"}, true, false, 0, MockReadFile)
+	assert.Error(t, err)
+}
+
+func TestLoadFormatsInvalid(t *testing.T) {
+	cases := []testCaseFormats{
+		{filePath: "mock.jsonl", input: []byte(`{"dependencies": ["foo.py"]}`)},
+		{filePath: "mock.dot", input: []byte(`foo -> bar`)},
+		{filePath: "mock.dot", input: []byte(`digraph { "foo -> bar }`)},
+		{filePath: "mock.graphml", input: []byte(`<graphml><graph>`)},
+	}
+	for _, testCase := range cases {
+		_, err := loadAdjacencyList(testCase.filePath, testCase.input)
+		assert.Error(t, err, string(testCase.input))
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+/*
+Read a dependency graph file and index it; if there's an up-to-date index written by the `index`
+command next to the dependency graph file, the graph is loaded from it instead of parsing the file.
+*/
+func loadGraph(filePath string, readFile ReadFileFunc) (*dggraph.Graph, error) {
+	data, err := readFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if graph := loadUpToDateIndex(filePath, data, readFile); graph != nil {
+		return graph, nil
+	}
+	graph, _, err := parseGraph(filePath, data)
+	return graph, err
+}
+
+// loadGraphWithAttributes reads a dependency graph file keeping node attributes if the format provides them
+func loadGraphWithAttributes(filePath string, readFile ReadFileFunc) (*dggraph.Graph, NodeAttributes, error) {
+	data, err := readFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return parseGraph(filePath, data)
+}
+
+// parseGraph parses file contents which can also be an index (that has no node attributes)
+func parseGraph(filePath string, data []byte) (*dggraph.Graph, NodeAttributes, error) {
+	if dggraph.IsIndex(data) {
+		graph, err := dggraph.DecodeIndex(data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("loading %s as index: %w", filePath, err)
+		}
+		return graph, nil, nil
+	}
+	adjacencyList, attributes, err := loadAdjacencyListWithAttributes(filePath, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return dggraph.NewGraph(adjacencyList), attributes, nil
+}
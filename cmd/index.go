@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+// extension of the index file written next to the dependency graph file by default
+const IndexExtension = ".idx"
+
+// to be used in non-unit tests
+var Index = index
+
+/*
+Write the index of a dependency graph file; the index is written next to the dependency graph file
+(e.g. `dg.idx` for `dg.json`) unless the index file path is set. The path of the index file is returned.
+*/
+func index(filePath string, indexPath string, readFile ReadFileFunc) (string, error) {
+	if indexPath == "" {
+		indexPath = defaultIndexPath(filePath)
+		if indexPath == "" {
+			return "", fmt.Errorf("index file path must be set when reading the dependency graph from stdin")
+		}
+	}
+	data, err := readFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	if dggraph.IsIndex(data) {
+		return "", fmt.Errorf("%s is already an index", filePath)
+	}
+	format, err := detectFormat(filePath)
+	if err != nil {
+		return "", err
+	}
+	graph, _, err := parseGraph(filePath, data)
+	if err != nil {
+		return "", err
+	}
+	header := dggraph.IndexHeader{Checksum: sha256.Sum256(data), Format: format}
+	if err := os.WriteFile(indexPath, dggraph.EncodeIndex(graph, header), 0o644); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
+
+// defaultIndexPath gets the path of the index next to the dependency graph file; there's none for stdin
+func defaultIndexPath(filePath string) string {
+	if filePath == StdinFilePath {
+		return ""
+	}
+	for _, extension := range dggraph.CompressionExtensions {
+		if strings.HasSuffix(strings.ToLower(filePath), extension) {
+			filePath = filePath[:len(filePath)-len(extension)]
+		}
+	}
+	return strings.TrimSuffix(filePath, filepath.Ext(filePath)) + IndexExtension
+}
+
+/*
+Load the index next to the dependency graph file if it exists and is up to date, i.e. it was
+written for the same contents of the dependency graph file parsed as the same format;
+nil is returned otherwise and the dependency graph file is to be parsed.
+*/
+func loadUpToDateIndex(filePath string, data []byte, readFile ReadFileFunc) *dggraph.Graph {
+	indexPath := defaultIndexPath(filePath)
+	if indexPath == "" || indexPath == filePath || dggraph.IsIndex(data) {
+		return nil
+	}
+	indexData, err := readFile(indexPath)
+	if err != nil || !dggraph.IsIndex(indexData) {
+		return nil
+	}
+	header, err := dggraph.DecodeIndexHeader(indexData)
+	if err != nil {
+		return nil
+	}
+	format, err := detectFormat(filePath)
+	if err != nil || header.Format != format || header.Checksum != sha256.Sum256(data) {
+		return nil
+	}
+	graph, err := dggraph.DecodeIndex(indexData)
+	if err != nil {
+		return nil
+	}
+	return graph
+}
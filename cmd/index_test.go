@@ -0,0 +1,76 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"crypto/sha256"
+	"path/filepath"
+	"testing"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultIndexPath(t *testing.T) {
+	assert.Equal(t, "dg.idx", defaultIndexPath("dg.json"))
+	assert.Equal(t, "data/dg.idx", defaultIndexPath("data/dg.dot.gz"))
+	assert.Equal(t, "dg.idx", defaultIndexPath("dg"))
+	assert.Equal(t, "", defaultIndexPath(StdinFilePath))
+}
+
+func TestLoadGraphFromIndex(t *testing.T) {
+	input := []byte(`{"foo.py": ["bar.py"]}`)
+	// the index is of a different graph to tell if it was used
+	indexed := dggraph.NewGraph(AdjacencyList{"foo.py": {"baz.py"}})
+	upToDate := dggraph.EncodeIndex(indexed, dggraph.IndexHeader{Checksum: sha256.Sum256(input), Format: FormatJson})
+	stale := dggraph.EncodeIndex(indexed, dggraph.IndexHeader{Checksum: sha256.Sum256([]byte("{}")), Format: FormatJson})
+	otherFormat := dggraph.EncodeIndex(indexed, dggraph.IndexHeader{Checksum: sha256.Sum256(input), Format: FormatJsonLines})
+
+	for _, testCase := range []struct {
+		index    []byte
+		expected []string
+	}{
+		{index: upToDate, expected: []string{"baz.py"}},
+		{index: stale, expected: []string{"bar.py"}},
+		{index: otherFormat, expected: []string{"bar.py"}},
+		{index: nil, expected: []string{"bar.py"}},
+	} {
+		MockReadFile := func(filePath string) ([]byte, error) {
+			if filePath == "mock.idx" {
+				return testCase.index, nil
+			}
+			return input, nil
+		}
+		result, err := dependencies("mock.json", []string{"foo.py"}, false, false, 0, MockReadFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, result)
+	}
+}
+
+func TestIndex(t *testing.T) {
+	input := []byte(`{"foo.py": ["bar.py"], "bar.py": ["baz.py"]}`)
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return input, nil
+	}
+	indexPath := filepath.Join(t.TempDir(), "dg.idx")
+	result, err := index("dg.json", indexPath, MockReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, indexPath, result)
+
+	// the index can be passed instead of the dependency graph file
+	deps, err := dependencies(indexPath, []string{"foo.py"}, true, false, 0, DefaultReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"bar.py", "baz.py"}, deps)
+
+	_, err = index(indexPath, "", DefaultReadFile)
+	assert.Error(t, err)
+	_, err = index(StdinFilePath, "", MockReadFile)
+	assert.Error(t, err)
+}
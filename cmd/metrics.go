@@ -4,10 +4,14 @@ Copyright © 2024 Alexey Tereshenkov
 package cmd
 
 import (
+	"cmp"
 	"encoding/json"
+	"fmt"
 	"log"
 	"slices"
 	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
 )
 
 type GenericMapStringToAny map[string]interface{}
@@ -18,6 +22,12 @@ const (
 	MetricReverseDependenciesDirect     = "rdeps-direct"
 	MetricReverseDependenciesTransitive = "rdeps-transitive"
 	MetricConnectedComponentsCount      = "components-count"
+	MetricHeight                        = "height"
+	MetricDepth                         = "depth"
+	MetricCriticalPath                  = "critical-path"
+	MetricBetweenness                   = "betweenness"
+	MetricPageRank                      = "pagerank"
+	MetricInstability                   = "instability"
 )
 
 var allowedMetrics = []string{
@@ -26,6 +36,91 @@ var allowedMetrics = []string{
 	MetricReverseDependenciesDirect,
 	MetricReverseDependenciesTransitive,
 	MetricConnectedComponentsCount,
+	MetricHeight,
+	MetricDepth,
+	MetricCriticalPath,
+	MetricBetweenness,
+	MetricPageRank,
+	MetricInstability,
+}
+
+// metrics reported when none are requested leaving out the costly metrics (betweenness and PageRank)
+var defaultMetrics = []string{
+	MetricDependenciesDirect,
+	MetricDependenciesTransitive,
+	MetricReverseDependenciesDirect,
+	MetricReverseDependenciesTransitive,
+	MetricConnectedComponentsCount,
+	MetricHeight,
+	MetricDepth,
+	MetricCriticalPath,
+	MetricInstability,
+}
+
+// metrics that have a single value for the whole graph rather than a value for every node
+var graphMetrics = []string{
+	MetricConnectedComponentsCount,
+	MetricCriticalPath,
+}
+
+// metricsOptions are the options of the metrics that need them and of the table of metrics
+type metricsOptions struct {
+	// number of nodes to sample when computing betweenness (0 for all nodes)
+	betweennessSamples int
+
+	// join metrics of every node in a row of a table; implied by the options below
+	table bool
+	// number of rows of the table to keep (0 for all rows)
+	top int
+	// metric to sort the rows by, the largest values first (the first metric by default)
+	sortBy string
+	// thresholds of the values of the metric the rows are sorted by
+	min *float64
+	max *float64
+}
+
+// tabular checks if the metrics are to be joined in a table
+func (options metricsOptions) tabular() bool {
+	return options.table || options.top > 0 || options.sortBy != "" || options.min != nil || options.max != nil
+}
+
+// MetricsTable has a row with the values of the metrics of every node
+type MetricsTable struct {
+	Metrics []string
+	Rows    []MetricsRow
+}
+
+// MetricsRow has the values of the metrics of a node in the order of the metrics of the table
+type MetricsRow struct {
+	Node   string
+	Values []any
+}
+
+// records are the rows as objects with the node and the values of the metrics by their names
+func (table MetricsTable) records() []map[string]any {
+	records := make([]map[string]any, len(table.Rows))
+	for i, row := range table.Rows {
+		records[i] = map[string]any{"node": row.Node}
+		for j, metric := range table.Metrics {
+			records[i][metric] = row.Values[j]
+		}
+	}
+	return records
+}
+
+func (table MetricsTable) MarshalJSON() ([]byte, error) {
+	return json.Marshal(table.records())
+}
+
+// metricValue gets the value of a metric of a node as a number to be compared
+func metricValue(value any) float64 {
+	switch number := value.(type) {
+	case int:
+		return float64(number)
+	case float64:
+		return number
+	}
+	return 0
 }
 
 func isValidMetric(metric string) bool {
@@ -37,141 +132,222 @@ func isValidMetric(metric string) bool {
 	return false
 }
 
+// countsMetric converts counts of every node into a metric of the report
+func countsMetric(counts map[string]int) GenericMapStringToAny {
+	metric := make(GenericMapStringToAny, len(counts))
+	for node, count := range counts {
+		metric[node] = count
+	}
+	return metric
+}
+
+// scoresMetric converts scores of every node into a metric of the report
+func scoresMetric(scores map[string]float64) GenericMapStringToAny {
+	metric := make(GenericMapStringToAny, len(scores))
+	for node, score := range scores {
+		metric[node] = score
+	}
+	return metric
+}
+
+// getConnectedComponentsCount gets count of connected components in a graph
+func getConnectedComponentsCount(graph *dggraph.Graph) GenericMapStringToAny {
+	connectedComponentsCount := make(GenericMapStringToAny)
+	connectedComponentsCount["count"] = len(dggraph.ConnectedComponents(graph))
+	return connectedComponentsCount
+}
+
+// getCriticalPath gets the longest chain of dependencies along with its length in edges
+func getCriticalPath(graph *dggraph.Graph) GenericMapStringToAny {
+	path := dggraph.CriticalPath(graph)
+	return GenericMapStringToAny{"length": max(len(path)-1, 0), "nodes": path}
+}
+
 /*
-Get direct dependencies given adjacency list (used both for dependencies and dependents)
-as it's just a mapping of node -> [nodes].
+Join the metrics of every node of a report into a table with rows sorted by the metric to sort by
+(the largest values first and then by the node) keeping only the rows within the thresholds and
+the top rows if requested.
 */
-func getMetricDependenciesDirect(adjacencyList AdjacencyList) GenericMapStringToAny {
-	depsCount := make(GenericMapStringToAny)
-	for key, deps := range adjacencyList {
-		depsCount[key] = len(deps)
-	}
-	// set count to 0 for nodes that were not present as keys in the adjacency list
-	// (i.e. they are only dependencies of some nodes and do not depend on anything)
-	for _, deps := range adjacencyList {
-		for _, dep := range deps {
-			if _, isKey := adjacencyList[dep]; !isKey {
-				depsCount[dep] = 0
-			}
+func getMetricsTable(report MetricsReport, metricsItems []string, options metricsOptions) MetricsTable {
+	sortBy := options.sortBy
+	if sortBy == "" {
+		sortBy = metricsItems[0]
+	}
+	table := MetricsTable{Metrics: metricsItems, Rows: []MetricsRow{}}
+	for _, node := range sortedKeys(report[sortBy]) {
+		value := metricValue(report[sortBy][node])
+		if (options.min != nil && value < *options.min) || (options.max != nil && value > *options.max) {
+			continue
 		}
+		row := MetricsRow{Node: node, Values: make([]any, len(metricsItems))}
+		for i, metric := range metricsItems {
+			row.Values[i] = report[metric][node]
+		}
+		table.Rows = append(table.Rows, row)
+	}
+	// the sort is stable, so rows with the same value stay sorted by the node
+	sortIndex := slices.Index(metricsItems, sortBy)
+	slices.SortStableFunc(table.Rows, func(a MetricsRow, b MetricsRow) int {
+		return cmp.Compare(metricValue(b.Values[sortIndex]), metricValue(a.Values[sortIndex]))
+	})
+	if options.top > 0 && len(table.Rows) > options.top {
+		table.Rows = table.Rows[:options.top]
 	}
-	return depsCount
+	return table
 }
 
 /*
-Get transitive dependencies given adjacency list (used both for dependencies and dependents)
-as it's just a mapping of node -> [nodes].
+Get the metrics to report for given options: the metric to sort a table by is reported even if not
+requested; only metrics of nodes can be joined in a table.
 */
-func getMetricDependenciesTransitive(adjacencyList AdjacencyList) GenericMapStringToAny {
-	depsCount := make(GenericMapStringToAny)
-	visited := make(map[string]int)
-	for dep := range adjacencyList {
-		if count, found := visited[dep]; found {
-			depsCount[dep] = count
-		} else {
-			transitiveDeps := getDepsTransitive(adjacencyList, []string{dep}, 0)
-			depsCount[dep] = len(transitiveDeps)
-			visited[dep] = len(transitiveDeps)
-		}
+func tableMetrics(metricsItems []string, options metricsOptions) ([]string, error) {
+	if !options.tabular() {
+		return metricsItems, nil
 	}
-	// set count to 0 for nodes that were not present as keys in the adjacency list
-	// (i.e. they are only dependencies of some nodes and do not depend on anything)
-	for _, deps := range adjacencyList {
-		for _, dep := range deps {
-			if _, isKey := adjacencyList[dep]; !isKey {
-				depsCount[dep] = 0
-			}
+	if options.sortBy != "" && !slices.Contains(metricsItems, options.sortBy) {
+		metricsItems = append(slices.Clone(metricsItems), options.sortBy)
+	}
+	if len(metricsItems) == 0 {
+		return nil, fmt.Errorf("metrics to join in a table are required")
+	}
+	for _, metric := range metricsItems {
+		if slices.Contains(graphMetrics, metric) {
+			return nil, fmt.Errorf("metric %s has a single value for the graph and cannot be joined in a table", metric)
 		}
 	}
-	return depsCount
+	return metricsItems, nil
 }
 
-// getConnectedComponentsCount gets count of connected components in a graph
-func getConnectedComponentsCount(adjacencyList AdjacencyList) GenericMapStringToAny {
-	connectedComponentsCount := make(GenericMapStringToAny)
-	connectedComponentsCount["count"] = len(getConnectedComponents(adjacencyList))
-	return connectedComponentsCount
+// metricsResult produces the report of given metrics or their table if requested given file paths
+func metricsResult(filePathDg string, filePathDgReverse string, metricsItems []string, options metricsOptions, readFile ReadFileFunc) (any, error) {
+	metricsItems, err := tableMetrics(metricsItems, options)
+	if err != nil {
+		return nil, err
+	}
+	report, err := metricsReport(filePathDg, filePathDgReverse, metricsItems, options, readFile)
+	// no report is produced if any of the metrics is invalid
+	if err != nil || report == nil {
+		return nil, err
+	}
+	if options.tabular() {
+		return getMetricsTable(report, metricsItems, options), nil
+	}
+	return report, nil
+}
+
+// getMetricsResult produces the report of given (valid) metrics or their table if requested
+func getMetricsResult(graph *dggraph.Graph, graphReverse *dggraph.Graph, metricsItems []string, options metricsOptions) any {
+	report := getMetricsReport(graph, graphReverse, metricsItems, options)
+	if options.tabular() {
+		return getMetricsTable(report, metricsItems, options)
+	}
+	return report
 }
 
 // to be used in non-unit tests
 var Metrics = metrics
 
 /*
-Produce data for given metrics.
+Produce data for given metrics as JSON.
 */
 func metrics(filePathDg string, filePathDgReverse string, metricsItems []string, readFile ReadFileFunc) ([]byte, error) {
-	var adjacencyList AdjacencyList
-	var adjacencyListReverse AdjacencyList
-
-	report := make(map[string]map[string]interface{})
-	// use dependencies adjacency list as is
-	if slices.Contains(metricsItems, MetricDependenciesDirect) ||
-		slices.Contains(metricsItems, MetricDependenciesTransitive) ||
-		slices.Contains(metricsItems, MetricConnectedComponentsCount) {
-		jsonData, err := readFile(filePathDg)
-		if err != nil {
-			return nil, err
+	report, err := metricsReport(filePathDg, filePathDgReverse, metricsItems, metricsOptions{}, readFile)
+	if err != nil || report == nil {
+		return []byte(""), err
+	}
+	reportJson, _ := json.MarshalIndent(report, "", "  ")
+	return reportJson, nil
+}
+
+/*
+Produce data for given metrics; no report is produced if any of the metrics is invalid.
+*/
+func metricsReport(filePathDg string, filePathDgReverse string, metricsItems []string, options metricsOptions, readFile ReadFileFunc) (MetricsReport, error) {
+	for _, metric := range metricsItems {
+		if !isValidMetric(metric) {
+			log.Printf("invalid metric: %s. Allowed metrics are: %s\n", metric, strings.Join(allowedMetrics, ","))
+			return nil, nil
 		}
-		adjacencyList, err = loadJsonFile(jsonData)
+	}
+	var graph *dggraph.Graph
+	var graphReverse *dggraph.Graph
+
+	// use dependencies graph as is for all metrics but the ones of dependents
+	if slices.ContainsFunc(metricsItems, func(metric string) bool {
+		return metric != MetricReverseDependenciesDirect && metric != MetricReverseDependenciesTransitive
+	}) {
+		dg, err := loadGraph(filePathDg, readFile)
 		if err != nil {
 			return nil, err
 		}
+		graph = dg
 	}
-	// use the reversed dependencies list if provided otherwise reverse the dependencies list first
+	// use the reversed dependencies graph if provided otherwise reverse the dependencies graph first
 	if slices.Contains(metricsItems, MetricReverseDependenciesDirect) || slices.Contains(metricsItems, MetricReverseDependenciesTransitive) {
 		if filePathDgReverse != "" {
-			jsonData, err := readFile(filePathDgReverse)
-			if err != nil {
-				return nil, err
-			}
-			adjacencyListReverse, err = loadJsonFile(jsonData)
+			dgReverse, err := loadGraph(filePathDgReverse, readFile)
 			if err != nil {
 				return nil, err
 			}
+			graphReverse = dgReverse
 		} else {
-			jsonData, err := readFile(filePathDg)
-			if err != nil {
-				return nil, err
-			}
-			adjacencyListTemp, err := loadJsonFile(jsonData)
-			if err != nil {
-				return nil, err
-			}
-			adjacencyListReverse = reverseAdjacencyLists(adjacencyListTemp)
-			// extending the map with the nodes that had no dependencies (e.g. {"foo": []} as "foo" won't be in reverse adjacency list
-			// if no one depends on "foo")
-			for key, deps := range adjacencyListTemp {
-				if len(deps) == 0 {
-					adjacencyListReverse[key] = []string{}
+			if graph == nil {
+				dg, err := loadGraph(filePathDg, readFile)
+				if err != nil {
+					return nil, err
 				}
+				graph = dg
 			}
+			graphReverse = graph.Reversed()
 		}
 	}
 
+	return getMetricsReport(graph, graphReverse, metricsItems, options), nil
+}
+
+/*
+Produce data for given (valid) metrics; the reversed graph is used for the metrics of dependents
+and is not required otherwise.
+*/
+func getMetricsReport(graph *dggraph.Graph, graphReverse *dggraph.Graph, metricsItems []string, options metricsOptions) MetricsReport {
+	report := make(MetricsReport)
 	for _, metric := range metricsItems {
-		if !isValidMetric(metric) {
-			log.Printf("invalid metric: %s. Allowed metrics are: %s\n", metric, strings.Join(allowedMetrics, ","))
-			return []byte(""), nil
-		}
 		switch metric {
 
 		case MetricDependenciesDirect:
-			report[metric] = getMetricDependenciesDirect(adjacencyList)
+			report[metric] = countsMetric(dggraph.DependencyCounts(graph))
 
 		case MetricDependenciesTransitive:
-			report[metric] = getMetricDependenciesTransitive(adjacencyList)
+			report[metric] = countsMetric(dggraph.TransitiveDependencyCounts(graph))
 
 		case MetricReverseDependenciesDirect:
-			report[metric] = getMetricDependenciesDirect(adjacencyListReverse)
+			report[metric] = countsMetric(dggraph.DependencyCounts(graphReverse))
 
 		case MetricReverseDependenciesTransitive:
-			report[metric] = getMetricDependenciesTransitive(adjacencyListReverse)
+			report[metric] = countsMetric(dggraph.TransitiveDependencyCounts(graphReverse))
 
 		case MetricConnectedComponentsCount:
-			report[metric] = getConnectedComponentsCount(adjacencyList)
+			report[metric] = getConnectedComponentsCount(graph)
+
+		case MetricHeight:
+			report[metric] = countsMetric(dggraph.Heights(graph))
+
+		case MetricDepth:
+			report[metric] = countsMetric(dggraph.Depths(graph))
+
+		case MetricCriticalPath:
+			report[metric] = getCriticalPath(graph)
+
+		case MetricBetweenness:
+			report[metric] = scoresMetric(dggraph.Betweenness(graph, options.betweennessSamples))
+
+		case MetricPageRank:
+			report[metric] = scoresMetric(dggraph.PageRank(graph))
+
+		case MetricInstability:
+			report[metric] = scoresMetric(dggraph.Instability(graph))
 
 		}
 	}
-	reportJson, _ := json.MarshalIndent(report, "", "  ")
-	return reportJson, nil
+	return report
 }
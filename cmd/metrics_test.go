@@ -314,21 +314,6 @@ func TestMetricsReverseDependenciesTransitive(t *testing.T) {
 				"bar.py":      0,
 			},
 		},
-		// looks like cyclic dependencies
-		{
-			input: []byte(`
-			{
-				"foo.py": [],
-				"bar.py": ["baz.py"],
-				"baz.py": ["foo.py"]
-			}
-			`),
-			expected: map[string]int{
-				"foo.py": 0,
-				"baz.py": 1,
-				"bar.py": 0,
-			},
-		},
 		// true cyclic dependencies
 		{
 			input: []byte(`
@@ -383,6 +368,32 @@ func TestMetricsReverseDependenciesTransitive(t *testing.T) {
 	}
 }
 
+/*
+A node declared without dependencies (`"foo.py": []`) that other nodes depend on used to have no dependents
+as its entry of the reversed dependency graph was replaced with an empty list when the graph was reversed,
+so both rdeps-direct and rdeps-transitive of "foo.py" were 0. The dependents are now counted.
+*/
+func TestMetricsReverseDependenciesOfNodeWithoutDependencies(t *testing.T) {
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return []byte(`
+		{
+			"foo.py": [],
+			"bar.py": ["baz.py"],
+			"baz.py": ["foo.py"]
+		}
+		`), nil
+	}
+	metricsItems := []string{MetricReverseDependenciesDirect, MetricReverseDependenciesTransitive}
+	result, err := metrics("mock.json", "", metricsItems, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	var actualOutput map[string]map[string]int
+	json.Unmarshal(result, &actualOutput)
+	assert.Equal(t, map[string]int{"foo.py": 1, "baz.py": 1, "bar.py": 0}, actualOutput["rdeps-direct"])
+	assert.Equal(t, map[string]int{"foo.py": 2, "baz.py": 1, "bar.py": 0}, actualOutput["rdeps-transitive"])
+}
+
 func TestMetricsConnectedComponentsCount(t *testing.T) {
 	cases := []TestCaseMetrics{
 		// base case
@@ -587,3 +598,99 @@ func TestMetricsCombined(t *testing.T) {
 		assert.True(t, exists, "Expected metric '%s' to exist in the report", metric)
 	}
 }
+
+func TestMetricsChains(t *testing.T) {
+	input := []byte(`
+	{
+		"app.py": ["db.py", "log.py"],
+		"db.py": ["orm.py", "log.py"],
+		"orm.py": ["db.py"]
+	}
+	`)
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return input, nil
+	}
+
+	metricsItems := []string{MetricHeight, MetricDepth, MetricCriticalPath}
+	report, err := metricsReport("mock.json", "", metricsItems, metricsOptions{}, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	// db.py and orm.py depend on each other, so they are measured as a single node
+	assert.Equal(t, GenericMapStringToAny{"app.py": 2, "db.py": 1, "orm.py": 1, "log.py": 0}, report[MetricHeight])
+	assert.Equal(t, GenericMapStringToAny{"app.py": 0, "db.py": 1, "orm.py": 1, "log.py": 2}, report[MetricDepth])
+	assert.Equal(t, GenericMapStringToAny{"length": 2, "nodes": []string{"app.py", "db.py|orm.py", "log.py"}}, report[MetricCriticalPath])
+
+	output, err := renderOutput(MetricsReport{MetricCriticalPath: report[MetricCriticalPath]}, OutputCsv, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "metric,node,value\ncritical-path,length,2\ncritical-path,nodes,app.py db.py|orm.py log.py\n", string(output))
+}
+
+func TestMetricsCentrality(t *testing.T) {
+	input := []byte(`
+	{
+		"app.py": ["core.py"],
+		"cli.py": ["core.py"],
+		"core.py": ["log.py"]
+	}
+	`)
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return input, nil
+	}
+
+	metricsItems := []string{MetricBetweenness, MetricInstability, MetricPageRank}
+	report, err := metricsReport("mock.json", "", metricsItems, metricsOptions{betweennessSamples: 10}, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, GenericMapStringToAny{"app.py": 0.0, "cli.py": 0.0, "core.py": 2.0, "log.py": 0.0}, report[MetricBetweenness])
+	assert.Equal(t, GenericMapStringToAny{"app.py": 1.0, "cli.py": 1.0, "core.py": 1.0 / 3, "log.py": 0.0}, report[MetricInstability])
+	assert.Greater(t, report[MetricPageRank]["log.py"], report[MetricPageRank]["core.py"])
+}
+
+func TestMetricsTable(t *testing.T) {
+	input := []byte(`
+	{
+		"app.py": ["core.py", "log.py"],
+		"cli.py": ["core.py"],
+		"core.py": ["log.py"],
+		"tools.py": ["log.py"]
+	}
+	`)
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return input, nil
+	}
+
+	minValue := 1.0
+	options := metricsOptions{top: 3, sortBy: MetricReverseDependenciesTransitive, min: &minValue}
+	result, err := metricsResult("mock.json", "", []string{MetricDependenciesDirect}, options, MockReadFile)
+	assert.NoError(t, err)
+	// the metric to sort by is added to the table and rows with the same value are sorted by the node
+	assert.Equal(t, MetricsTable{
+		Metrics: []string{MetricDependenciesDirect, MetricReverseDependenciesTransitive},
+		Rows: []MetricsRow{
+			{Node: "log.py", Values: []any{0, 4}},
+			{Node: "core.py", Values: []any{1, 2}},
+		},
+	}, result)
+
+	output, err := renderOutput(result, OutputText, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "node     deps-direct  rdeps-transitive\nlog.py   0            4\ncore.py  1            2\n", string(output))
+	output, err = renderOutput(result, OutputJson, renderOptions{})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"node":"log.py","deps-direct":0,"rdeps-transitive":4},{"node":"core.py","deps-direct":1,"rdeps-transitive":2}]`, string(output))
+
+	// rows are sorted by the first metric by default
+	result, err = metricsResult("mock.json", "", []string{MetricDependenciesDirect}, metricsOptions{top: 1}, MockReadFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []MetricsRow{{Node: "app.py", Values: []any{2}}}, result.(MetricsTable).Rows)
+
+	_, err = metricsResult("mock.json", "", []string{MetricCriticalPath}, metricsOptions{table: true}, MockReadFile)
+	assert.Error(t, err)
+	_, err = metricsResult("mock.json", "", []string{}, metricsOptions{table: true}, MockReadFile)
+	assert.Error(t, err)
+}
@@ -0,0 +1,394 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/spf13/cobra"
+)
+
+// optionKind is the type of the value of a query option
+type optionKind int
+
+const (
+	boolOption optionKind = iota
+	intOption
+	floatOption
+	durationOption
+	stringOption
+	// values can be repeated or separated by commas, e.g. metrics
+	listOption
+	/*
+		node names or patterns which are not split on commas so that node names can contain them;
+		a flag of a command takes a single target while the server and the shell take repeated values
+	*/
+	targetOption
+)
+
+/*
+queryOption is an option of a query defined once for all front-ends: the flags of the command,
+the parameters of the endpoint of the server (see serve), and the flags of the statement of the shell.
+*/
+type queryOption struct {
+	name string
+	kind optionKind
+	// default value of the option which is the zero value of its kind if empty
+	value string
+	// the option must have a value
+	required bool
+	// placeholder of the value in the usage of shell statements (derived from the kind if empty)
+	placeholder string
+	usage       string
+}
+
+// queryOptions are the options of the queries by the names of their commands
+var queryOptions = map[string][]queryOption{
+	"dependencies": {
+		{name: "transitive", kind: boolOption, usage: "Get transitive dependencies"},
+		{name: "reflexive", kind: boolOption, usage: "Include input targets in the output"},
+		{name: "depth", kind: intOption, usage: "Depth of search for transitive dependencies"},
+	},
+	"dependents": {
+		{name: "transitive", kind: boolOption, usage: "Get transitive dependents"},
+		{name: "reflexive", kind: boolOption, usage: "Include input targets in the output"},
+		{name: "depth", kind: intOption, usage: "Depth of search for transitive dependents"},
+	},
+	"paths": {
+		{name: "from", kind: targetOption, required: true, placeholder: "<targets>", usage: "Find path from this target"},
+		{name: "to", kind: targetOption, required: true, placeholder: "<targets>", usage: "Find path to this target"},
+		{name: "n", kind: intOption, usage: "Only return first n paths between targets"},
+	},
+	"cycles": {
+		{name: "max-length", kind: intOption, usage: "Only find cycles of at most this many nodes"},
+		{name: "max-cycles", kind: intOption, usage: "Stop after finding this many cycles"},
+		{name: "through", kind: listOption, placeholder: "<targets>", usage: "Only find cycles going through any of these nodes (patterns are supported)"},
+		{name: "timeout", kind: durationOption, usage: "Stop finding cycles after this time (e.g. 30s) listing the cycles found so far"},
+		{name: "suggest-breaks", kind: boolOption, usage: "Suggest edges to remove to make the dependency graph acyclic ranked by the number of cycles going through them"},
+		{name: "acyclic", kind: boolOption, usage: "Write the dependency graph without the edges suggested to be removed to make it acyclic (cannot be combined with the options limiting the search of cycles)"},
+	},
+	"components": {
+		{name: "strong", kind: boolOption, usage: "List strongly connected components with their sizes"},
+		{name: "min-size", kind: intOption, value: "1", usage: "Only list strongly connected components of at least this size"},
+	},
+	"subgraph": {
+		{name: "root", kind: targetOption, required: true, placeholder: "<targets>", usage: "Root node for the subgraph to extract"},
+	},
+	"simplify": {
+		{name: "technique", kind: stringOption, placeholder: "<technique>", usage: "Technique to simplify the dependency graph"},
+	},
+	"metrics": {
+		{name: "metric", kind: listOption, placeholder: "<metrics>", usage: "Metrics to report (all but betweenness and pagerank by default): " + strings.Join(allowedMetrics, ", ")},
+		{name: "betweenness-samples", kind: intOption, usage: "Compute betweenness from this many randomly chosen nodes instead of all nodes (faster on large graphs)"},
+		{name: "table", kind: boolOption, usage: "Join the metrics of every node in a row of a table"},
+		{name: "top", kind: intOption, usage: "Only list this many rows of the table"},
+		{name: "sort-by", kind: stringOption, placeholder: "<metric>", usage: "Metric to sort the rows of the table by, the largest values first (the first metric by default)"},
+		{name: "min", kind: floatOption, usage: "Only list the rows of the table with the metric to sort by at least this value"},
+		{name: "max", kind: floatOption, usage: "Only list the rows of the table with the metric to sort by at most this value"},
+	},
+	"diff": {
+		{name: "top", kind: intOption, value: "10", usage: "Only list this many nodes whose numbers of transitive dependencies changed the most (0 for all)"},
+		{name: "max-length", kind: intOption, usage: "Only find new cycles of at most this many nodes"},
+		{name: "max-cycles", kind: intOption, usage: "Stop after finding this many cycles"},
+		{name: "timeout", kind: durationOption, usage: "Stop finding new cycles after this time (e.g. 30s) listing the cycles found so far"},
+	},
+	"toposort": {
+		{name: "layers", kind: boolOption, usage: "Group nodes into layers that can be built in parallel"},
+	},
+}
+
+// lookupOption finds an option of a query
+func lookupOption(command string, name string) (queryOption, bool) {
+	index := slices.IndexFunc(queryOptions[command], func(option queryOption) bool { return option.name == name })
+	if index < 0 {
+		return queryOption{}, false
+	}
+	return queryOptions[command][index], true
+}
+
+// addOptionFlags registers the options of the query of a command as its flags
+func addOptionFlags(command *cobra.Command) {
+	flags := command.Flags()
+	for _, option := range queryOptions[command.Name()] {
+		switch option.kind {
+		case boolOption:
+			flags.Bool(option.name, option.value == "true", option.usage)
+		case intOption:
+			value, _ := strconv.Atoi(option.value)
+			flags.Int(option.name, value, option.usage)
+		case floatOption:
+			value, _ := strconv.ParseFloat(option.value, 64)
+			flags.Float64(option.name, value, option.usage)
+		case durationOption:
+			value, _ := time.ParseDuration(option.value)
+			flags.Duration(option.name, value, option.usage)
+		case stringOption, targetOption:
+			flags.String(option.name, option.value, option.usage)
+		case listOption:
+			flags.StringSlice(option.name, []string{}, option.usage)
+		}
+	}
+}
+
+// optionsUsage describes the options of the query of a command for the usage of shell statements
+func optionsUsage(command string) string {
+	usage := []string{}
+	for _, option := range queryOptions[command] {
+		placeholder := option.placeholder
+		if placeholder == "" {
+			placeholder = map[optionKind]string{intOption: "N", floatOption: "X", durationOption: "<duration>", stringOption: "<value>"}[option.kind]
+		}
+		flag := "--" + option.name
+		if option.kind != boolOption {
+			flag += "=" + placeholder
+		}
+		if !option.required {
+			flag = "[" + flag + "]"
+		}
+		usage = append(usage, flag)
+	}
+	return strings.Join(usage, " ")
+}
+
+// optionError is an error caused by invalid options (flags or parameters of requests) rather than by the query itself
+type optionError struct {
+	message string
+}
+
+func (err optionError) Error() string {
+	return err.message
+}
+
+/*
+optionReader reads the typed values of the options of a query set in a front-end keeping the first error;
+an option that is not set has its default value.
+*/
+type optionReader struct {
+	command string
+	// values of an option set in the front-end (none if it's not set)
+	lookup func(option queryOption) []string
+	// description of an option in errors, e.g. `flag --depth` or `parameter "depth"`
+	describe func(name string) string
+	err      error
+}
+
+// flagOptions reads the options of a command from its flags
+func flagOptions(cmd *cobra.Command) *optionReader {
+	return &optionReader{
+		command: cmd.Name(),
+		lookup: func(option queryOption) []string {
+			flag := cmd.Flags().Lookup(option.name)
+			if flag == nil || !flag.Changed {
+				return nil
+			}
+			if option.kind == listOption {
+				values, _ := cmd.Flags().GetStringSlice(option.name)
+				return values
+			}
+			return []string{flag.Value.String()}
+		},
+		describe: func(name string) string { return "flag --" + name },
+	}
+}
+
+func (reader *optionReader) fail(format string, args ...any) {
+	if reader.err == nil {
+		reader.err = optionError{fmt.Sprintf(format, args...)}
+	}
+}
+
+// values gets the values of an option which are the default value if the option is not set
+func (reader *optionReader) values(name string) []string {
+	option, exists := lookupOption(reader.command, name)
+	if !exists {
+		panic(fmt.Sprintf("undefined option %s of %s", name, reader.command))
+	}
+	values := reader.lookup(option)
+	if option.required && !slices.ContainsFunc(values, func(value string) bool { return value != "" }) {
+		reader.fail("%s is required", reader.describe(name))
+	}
+	if len(values) > 0 {
+		return values
+	}
+	if option.value == "" {
+		return nil
+	}
+	return []string{option.value}
+}
+
+// value gets the last value of an option so that a repeated option overrides the previous values
+func (reader *optionReader) value(name string) string {
+	values := reader.values(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// isSet tells whether an option is set in the front-end rather than having its default value
+func (reader *optionReader) isSet(name string) bool {
+	option, _ := lookupOption(reader.command, name)
+	return slices.ContainsFunc(reader.lookup(option), func(value string) bool { return value != "" })
+}
+
+func (reader *optionReader) bool(name string) bool {
+	value := reader.value(name)
+	if value == "" {
+		return false
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		reader.fail("%s must be a boolean", reader.describe(name))
+	}
+	return result
+}
+
+func (reader *optionReader) int(name string) int {
+	value := reader.value(name)
+	if value == "" {
+		return 0
+	}
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		reader.fail("%s must be an integer", reader.describe(name))
+	}
+	return result
+}
+
+// optionalFloat reads a number that is nil if the option is not set
+func (reader *optionReader) optionalFloat(name string) *float64 {
+	value := reader.value(name)
+	if value == "" {
+		return nil
+	}
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		reader.fail("%s must be a number", reader.describe(name))
+	}
+	return &result
+}
+
+func (reader *optionReader) duration(name string) time.Duration {
+	value := reader.value(name)
+	if value == "" {
+		return 0
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		reader.fail("%s must be a duration", reader.describe(name))
+	}
+	return result
+}
+
+func (reader *optionReader) string(name string) string {
+	return reader.value(name)
+}
+
+// list reads all values of an option splitting comma-separated values and dropping empty and repeated values
+func (reader *optionReader) list(name string) []string {
+	result := []string{}
+	for _, value := range reader.values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if item != "" && !slices.Contains(result, item) {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// targets reads all values of an option dropping empty and repeated values
+func (reader *optionReader) targets(name string) []string {
+	result := []string{}
+	for _, value := range reader.values(name) {
+		if value != "" && !slices.Contains(result, value) {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// dependencyOptions reads the options of dependencies and dependents
+func (reader *optionReader) dependencyOptions() dggraph.DependencyOptions {
+	return dggraph.DependencyOptions{
+		Transitive: reader.bool("transitive"),
+		Reflexive:  reader.bool("reflexive"),
+		Depth:      reader.int("depth"),
+	}
+}
+
+// cycleOptions reads the options limiting the search of cycles
+func (reader *optionReader) cycleOptions() dggraph.CycleOptions {
+	options := dggraph.CycleOptions{
+		MaxLength: reader.int("max-length"),
+		MaxCycles: reader.int("max-cycles"),
+		Timeout:   reader.duration("timeout"),
+	}
+	// diff searches all new cycles so it has no option to only find cycles going through some nodes
+	if _, exists := lookupOption(reader.command, "through"); exists {
+		options.Through = reader.list("through")
+	}
+	return options
+}
+
+/*
+acyclic reads whether to write the graph without the edges suggested to be removed to make it acyclic;
+these edges are found without searching cycles, so the options limiting the search cannot be set along with it.
+*/
+func (reader *optionReader) acyclic() bool {
+	acyclic := reader.bool("acyclic")
+	if !acyclic {
+		return false
+	}
+	for _, name := range []string{"max-length", "max-cycles", "through", "timeout"} {
+		if reader.isSet(name) {
+			reader.fail("%s cannot be combined with %s", reader.describe("acyclic"), reader.describe(name))
+		}
+	}
+	return acyclic
+}
+
+// technique reads the technique to simplify the graph with checking that it's valid (there's no default technique)
+func (reader *optionReader) technique() string {
+	technique := reader.string("technique")
+	if !isValidTechnique(technique) {
+		reader.fail("invalid technique: %s. Allowed techniques are: %s", technique, strings.Join(allowedTechniques, ","))
+	}
+	return technique
+}
+
+/*
+Read the metrics to report (the default metrics unless they're joined in a table) along with the options
+of the metrics checking that the metrics are valid and can be reported for the options.
+*/
+func (reader *optionReader) metrics() ([]string, metricsOptions) {
+	options := metricsOptions{
+		betweennessSamples: reader.int("betweenness-samples"),
+		table:              reader.bool("table"),
+		top:                reader.int("top"),
+		sortBy:             reader.string("sort-by"),
+		min:                reader.optionalFloat("min"),
+		max:                reader.optionalFloat("max"),
+	}
+	metricsItems := reader.list("metric")
+	if len(metricsItems) == 0 && !options.tabular() {
+		metricsItems = defaultMetrics
+	}
+	metricsItems, err := tableMetrics(metricsItems, options)
+	if err != nil {
+		reader.fail("%s", err)
+		return nil, options
+	}
+	for _, metric := range metricsItems {
+		if !isValidMetric(metric) {
+			reader.fail("invalid metric: %s. Allowed metrics are: %s", metric, strings.Join(allowedMetrics, ","))
+		}
+	}
+	return metricsItems, options
+}
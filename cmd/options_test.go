@@ -0,0 +1,153 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptionFlags(t *testing.T) {
+	// every option of a query is a flag of its command
+	for command, options := range queryOptions {
+		cobraCommand, _, err := RootCmd.Find([]string{command})
+		if !assert.NoError(t, err, command) {
+			continue
+		}
+		assert.Equal(t, command, cobraCommand.Name())
+		for _, option := range options {
+			flag := cobraCommand.Flags().Lookup(option.name)
+			if assert.NotNil(t, flag, command+" --"+option.name) {
+				assert.Equal(t, option.usage, flag.Usage)
+			}
+		}
+	}
+}
+
+func TestOptionReader(t *testing.T) {
+	options := shellFlags{
+		"from":  {"a.py,b.py", "a.py", "", "a.py"},
+		"depth": {"1", "2"},
+	}
+	reader := options.options("paths")
+	// node names can contain commas
+	assert.Equal(t, []string{"a.py,b.py", "a.py"}, reader.targets("from"))
+	assert.Equal(t, 0, reader.int("n"))
+	assert.NoError(t, reader.err)
+
+	reader = options.options("dependencies")
+	assert.Equal(t, dggraph.DependencyOptions{Depth: 2}, reader.dependencyOptions())
+	assert.NoError(t, reader.err)
+
+	// options that are not set have their default values
+	reader = shellFlags{}.options("components")
+	assert.Equal(t, 1, reader.int("min-size"))
+	reader = shellFlags{"timeout": {"1s"}, "through": {"a.py"}}.options("cycles")
+	assert.Equal(t, dggraph.CycleOptions{Timeout: time.Second, Through: []string{"a.py"}}, reader.cycleOptions())
+	assert.NoError(t, reader.err)
+
+	// diff has the options limiting the search of cycles but through
+	reader = shellFlags{"max-cycles": {"5"}}.options("diff")
+	assert.Equal(t, dggraph.CycleOptions{MaxCycles: 5}, reader.cycleOptions())
+	assert.Equal(t, 10, reader.int("top"))
+	assert.NoError(t, reader.err)
+}
+
+func TestOptionReaderDefaultMetrics(t *testing.T) {
+	// the costly metrics are only reported when requested
+	reader := shellFlags{}.options("metrics")
+	metricsItems, _ := reader.metrics()
+	assert.Equal(t, defaultMetrics, metricsItems)
+	assert.NotContains(t, metricsItems, MetricBetweenness)
+	assert.NotContains(t, metricsItems, MetricPageRank)
+	assert.NoError(t, reader.err)
+
+	reader = shellFlags{"metric": {"betweenness,pagerank"}}.options("metrics")
+	metricsItems, _ = reader.metrics()
+	assert.Equal(t, []string{MetricBetweenness, MetricPageRank}, metricsItems)
+	assert.NoError(t, reader.err)
+}
+
+func TestOptionReaderInvalid(t *testing.T) {
+	type testCaseOptions struct {
+		command  string
+		options  shellFlags
+		read     func(reader *optionReader)
+		expected string
+	}
+	cases := []testCaseOptions{
+		{
+			command:  "paths",
+			options:  shellFlags{"from": {"a.py"}, "to": {""}},
+			read:     func(reader *optionReader) { reader.targets("from"); reader.targets("to") },
+			expected: "flag --to is required",
+		},
+		{
+			command:  "dependencies",
+			options:  shellFlags{"transitive": {"maybe"}, "depth": {"one"}},
+			read:     func(reader *optionReader) { reader.dependencyOptions() },
+			expected: "flag --transitive must be a boolean",
+		},
+		{
+			command:  "cycles",
+			options:  shellFlags{"timeout": {"soon"}},
+			read:     func(reader *optionReader) { reader.cycleOptions() },
+			expected: "flag --timeout must be a duration",
+		},
+		{
+			command:  "cycles",
+			options:  shellFlags{"acyclic": {"true"}, "through": {"a.py"}},
+			read:     func(reader *optionReader) { reader.acyclic() },
+			expected: "flag --acyclic cannot be combined with flag --through",
+		},
+		{
+			command:  "metrics",
+			options:  shellFlags{"min": {"low"}},
+			read:     func(reader *optionReader) { reader.metrics() },
+			expected: "flag --min must be a number",
+		},
+		{
+			command:  "metrics",
+			options:  shellFlags{"metric": {"height,size"}},
+			read:     func(reader *optionReader) { reader.metrics() },
+			expected: "invalid metric: size. Allowed metrics are: deps-direct,deps-transitive,rdeps-direct,rdeps-transitive,components-count,height,depth,critical-path,betweenness,pagerank,instability",
+		},
+		{
+			command:  "metrics",
+			options:  shellFlags{"table": {"true"}},
+			read:     func(reader *optionReader) { reader.metrics() },
+			expected: "metrics to join in a table are required",
+		},
+		// there's no default technique
+		{
+			command:  "simplify",
+			options:  shellFlags{},
+			read:     func(reader *optionReader) { reader.technique() },
+			expected: "invalid technique: . Allowed techniques are: transitive-reduction",
+		},
+		{
+			command:  "simplify",
+			options:  shellFlags{"technique": {"pruning"}},
+			read:     func(reader *optionReader) { reader.technique() },
+			expected: "invalid technique: pruning. Allowed techniques are: transitive-reduction",
+		},
+	}
+	for _, testCase := range cases {
+		reader := testCase.options.options(testCase.command)
+		testCase.read(reader)
+		assert.IsType(t, optionError{}, reader.err, testCase.expected)
+		assert.EqualError(t, reader.err, testCase.expected)
+	}
+}
+
+func TestOptionsUsage(t *testing.T) {
+	assert.Equal(t, "--from=<targets> --to=<targets> [--n=N]", optionsUsage("paths"))
+	assert.Equal(t, "[--transitive] [--reflexive] [--depth=N]", optionsUsage("dependencies"))
+	assert.Equal(t, "", optionsUsage("roots"))
+	assert.Equal(t, "query <expression> (shell variables such as $last can be used in the expression)", shellUsage("query"))
+	assert.Equal(t, "toposort [--layers]", shellUsage("toposort"))
+}
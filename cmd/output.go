@@ -0,0 +1,283 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	OutputText      = "text"
+	OutputJson      = "json"
+	OutputJsonLines = "jsonl"
+	OutputCsv       = "csv"
+	OutputDot       = "dot"
+	OutputMermaid   = "mermaid"
+)
+
+var allowedOutputs = []string{
+	OutputText,
+	OutputJson,
+	OutputJsonLines,
+	OutputCsv,
+	OutputDot,
+	OutputMermaid,
+}
+
+// MetricsReport maps every requested metric to its values
+type MetricsReport map[string]GenericMapStringToAny
+
+/*
+Write the result of a command in the format set with the `--output` flag or in the
+default format of the command if the flag is not set. The supported results are
+lists of nodes, lists of node groups (e.g. paths), affected nodes, graph diffs, rule violations, build layers,
+strongly connected components, edges breaking cycles, adjacency lists, node attributes,
+and metrics reports and tables. The targets of the query are highlighted when rendering a graph.
+*/
+func writeOutput(cmd *cobra.Command, result any, defaultFormat string, targets ...string) error {
+	format := outputFormat
+	if format == "" {
+		format = defaultFormat
+	}
+	output, err := renderOutput(result, format, newRenderOptions(targets))
+	if err != nil {
+		return err
+	}
+	cmd.OutOrStdout().Write(output)
+	return nil
+}
+
+// renderOutput renders the result of a command in given format
+func renderOutput(result any, format string, options renderOptions) ([]byte, error) {
+	switch format {
+	case OutputText:
+		return renderText(result)
+	case OutputJson:
+		resultJson, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(resultJson, '\n'), nil
+	case OutputJsonLines:
+		return renderJsonLines(result)
+	case OutputCsv:
+		return renderCsv(result)
+	case OutputDot, OutputMermaid:
+		graph, err := resultGraph(result)
+		if err != nil {
+			return nil, err
+		}
+		if format == OutputDot {
+			return renderDot(graph, options), nil
+		}
+		return renderMermaid(graph, options), nil
+	}
+	return nil, fmt.Errorf("invalid output: %s. Allowed outputs are: %s", format, strings.Join(allowedOutputs, ","))
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// resultRows flattens a result into rows shared by the text, JSON Lines, and CSV outputs
+func resultRows(result any) (header []string, rows [][]string, records []any, err error) {
+	switch value := result.(type) {
+	case []string:
+		for _, node := range value {
+			rows = append(rows, []string{node})
+			records = append(records, node)
+		}
+		return []string{"node"}, rows, records, nil
+	case [][]string:
+		for _, group := range value {
+			rows = append(rows, group)
+			records = append(records, group)
+		}
+		return nil, rows, records, nil
+	case Cycles:
+		return resultRows([][]string(value))
+	case StrongComponents:
+		for _, component := range value {
+			rows = append(rows, []string{fmt.Sprint(component.Size), formatValue(component.Nodes)})
+			records = append(records, component)
+		}
+		return []string{"size", "nodes"}, rows, records, nil
+	case Layers:
+		for i, layer := range value {
+			rows = append(rows, []string{fmt.Sprint(i), formatValue(layer)})
+			records = append(records, map[string]any{"layer": i, "nodes": layer})
+		}
+		return []string{"layer", "nodes"}, rows, records, nil
+	case CycleBreaks:
+		for _, edge := range value {
+			rows = append(rows, []string{edge.From, edge.To, fmt.Sprint(edge.Cycles)})
+			records = append(records, edge)
+		}
+		return []string{"from", "to", "cycles"}, rows, records, nil
+	case Affected:
+		for _, node := range value {
+			rows = append(rows, []string{node.Node, formatValue(node.Reason)})
+			records = append(records, node)
+		}
+		return []string{"node", "reason"}, rows, records, nil
+	case Violations:
+		for _, violation := range value {
+			rows = append(rows, []string{violation.Rule, violation.From, violation.To, violation.Message})
+			records = append(records, violation)
+		}
+		return []string{"rule", "from", "to", "message"}, rows, records, nil
+	case GraphDiff:
+		rows, records = diffRows(value)
+		return []string{"change", "value"}, rows, records, nil
+	case AdjacencyList:
+		for _, node := range sortedKeys(value) {
+			if len(value[node]) == 0 {
+				rows = append(rows, []string{node, ""})
+			}
+			for _, dep := range value[node] {
+				rows = append(rows, []string{node, dep})
+			}
+			records = append(records, map[string]any{"node": node, "dependencies": value[node]})
+		}
+		return []string{"source", "target"}, rows, records, nil
+	case NodeAttributes:
+		for _, node := range sortedKeys(value) {
+			for _, attribute := range sortedKeys(value[node]) {
+				rows = append(rows, []string{node, attribute, formatValue(value[node][attribute])})
+			}
+			records = append(records, map[string]any{"node": node, "attributes": value[node]})
+		}
+		return []string{"node", "attribute", "value"}, rows, records, nil
+	case MetricsTable:
+		tableRecords := value.records()
+		for i, row := range value.Rows {
+			cells := []string{row.Node}
+			for _, metricValue := range row.Values {
+				cells = append(cells, formatValue(metricValue))
+			}
+			rows = append(rows, cells)
+			records = append(records, tableRecords[i])
+		}
+		return append([]string{"node"}, value.Metrics...), rows, records, nil
+	case MetricsReport:
+		for _, metric := range sortedKeys(value) {
+			for _, node := range sortedKeys(value[metric]) {
+				rows = append(rows, []string{metric, node, formatValue(value[metric][node])})
+				records = append(records, map[string]any{"metric": metric, "node": node, "value": value[metric][node]})
+			}
+		}
+		return []string{"metric", "node", "value"}, rows, records, nil
+	}
+	return nil, nil, nil, fmt.Errorf("unsupported result type %T", result)
+}
+
+func formatValue(value any) string {
+	if values, isList := value.([]string); isList {
+		return strings.Join(values, " ")
+	}
+	return fmt.Sprint(value)
+}
+
+/*
+Render a result as plain text: one node per line for lists of nodes, one space-separated
+group per line for lists of node groups, and one row per line for everything else.
+*/
+func renderText(result any) ([]byte, error) {
+	var output bytes.Buffer
+	switch value := result.(type) {
+	case []string:
+		output.WriteString(strings.Join(value, "\n"))
+		output.WriteString("\n")
+		return output.Bytes(), nil
+	case AdjacencyList:
+		for _, node := range sortedKeys(value) {
+			if len(value[node]) == 0 {
+				fmt.Fprintln(&output, node)
+			}
+			for _, dep := range value[node] {
+				fmt.Fprintf(&output, "%s -> %s\n", node, dep)
+			}
+		}
+		return output.Bytes(), nil
+	case Affected:
+		// the reasons are left out so that the nodes can be passed on to other tools
+		for _, node := range value {
+			fmt.Fprintln(&output, node.Node)
+		}
+		return output.Bytes(), nil
+	case Violations:
+		for _, violation := range value {
+			if violation.To == "" {
+				fmt.Fprintf(&output, "%s: %s: %s\n", violation.Rule, violation.From, violation.Message)
+			} else {
+				fmt.Fprintf(&output, "%s: %s -> %s: %s\n", violation.Rule, violation.From, violation.To, violation.Message)
+			}
+		}
+		return output.Bytes(), nil
+	case GraphDiff:
+		return renderDiff(value), nil
+	case MetricsTable:
+		// columns are aligned with a header
+		header, rows, _, _ := resultRows(value)
+		writer := tabwriter.NewWriter(&output, 0, 0, 2, ' ', 0)
+		for _, row := range append([][]string{header}, rows...) {
+			fmt.Fprintln(writer, strings.Join(row, "\t"))
+		}
+		writer.Flush()
+		return output.Bytes(), nil
+	}
+	_, rows, _, err := resultRows(result)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		output.WriteString(strings.Join(row, " "))
+		output.WriteString("\n")
+	}
+	return output.Bytes(), nil
+}
+
+// renderJsonLines renders a result as one JSON value per line
+func renderJsonLines(result any) ([]byte, error) {
+	_, _, records, err := resultRows(result)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	encoder := json.NewEncoder(&output)
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return nil, err
+		}
+	}
+	return output.Bytes(), nil
+}
+
+// renderCsv renders a result as CSV with a header row (if the result has named columns)
+func renderCsv(result any) ([]byte, error) {
+	header, rows, _, err := resultRows(result)
+	if err != nil {
+		return nil, err
+	}
+	var output bytes.Buffer
+	writer := csv.NewWriter(&output)
+	if header != nil {
+		writer.Write(header)
+	}
+	writer.WriteAll(rows)
+	return output.Bytes(), writer.Error()
+}
@@ -0,0 +1,86 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseOutput struct {
+	result   any
+	format   string
+	expected string
+}
+
+func TestRenderOutput(t *testing.T) {
+	nodes := []string{"bar.py", "foo.py"}
+	paths := [][]string{{"foo.py", "bar.py", "baz.py"}}
+	adjacencyList := AdjacencyList{"foo.py": {"bar.py", "baz.py"}, "spam.py": {}}
+	report := MetricsReport{"deps-direct": {"foo.py": 2, "bar.py": 0}}
+
+	cases := []testCaseOutput{
+		{result: nodes, format: OutputText, expected: "bar.py\nfoo.py\n"},
+		{result: []string{}, format: OutputText, expected: "\n"},
+		{result: nodes, format: OutputJson, expected: "[\n  \"bar.py\",\n  \"foo.py\"\n]\n"},
+		{result: nodes, format: OutputJsonLines, expected: "\"bar.py\"\n\"foo.py\"\n"},
+		{result: nodes, format: OutputCsv, expected: "node\nbar.py\nfoo.py\n"},
+		{result: paths, format: OutputText, expected: "foo.py bar.py baz.py\n"},
+		{result: paths, format: OutputJsonLines, expected: "[\"foo.py\",\"bar.py\",\"baz.py\"]\n"},
+		{result: paths, format: OutputCsv, expected: "foo.py,bar.py,baz.py\n"},
+		{
+			result:   paths,
+			format:   OutputDot,
+			expected: "digraph dg {\n  \"baz.py\";\n  \"bar.py\" -> \"baz.py\";\n  \"foo.py\" -> \"bar.py\";\n}\n",
+		},
+		{
+			result:   adjacencyList,
+			format:   OutputText,
+			expected: "foo.py -> bar.py\nfoo.py -> baz.py\nspam.py\n",
+		},
+		{
+			result:   adjacencyList,
+			format:   OutputJsonLines,
+			expected: "{\"dependencies\":[\"bar.py\",\"baz.py\"],\"node\":\"foo.py\"}\n{\"dependencies\":[],\"node\":\"spam.py\"}\n",
+		},
+		{
+			result:   adjacencyList,
+			format:   OutputCsv,
+			expected: "source,target\nfoo.py,bar.py\nfoo.py,baz.py\nspam.py,\n",
+		},
+		{
+			result:   adjacencyList,
+			format:   OutputMermaid,
+			expected: "flowchart TD\n  n0[\"bar.py\"]\n  n1[\"baz.py\"]\n  n2[\"foo.py\"]\n  n3[\"spam.py\"]\n  n2 --> n0\n  n2 --> n1\n",
+		},
+		{
+			result:   report,
+			format:   OutputCsv,
+			expected: "metric,node,value\ndeps-direct,bar.py,0\ndeps-direct,foo.py,2\n",
+		},
+		{
+			result:   NodeAttributes{"foo.py": {AttributeSources: []string{"a.py", "b.py"}}},
+			format:   OutputText,
+			expected: "foo.py sources a.py b.py\n",
+		},
+	}
+
+	for _, testCase := range cases {
+		result, err := renderOutput(testCase.result, testCase.format, renderOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, string(result), testCase.format)
+	}
+}
+
+func TestRenderOutputInvalid(t *testing.T) {
+	_, err := renderOutput([]string{"foo.py"}, "yaml", renderOptions{})
+	assert.Error(t, err)
+
+	// metrics cannot be rendered as a graph
+	_, err = renderOutput(MetricsReport{}, OutputDot, renderOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var pantsPeekOutput = []byte(`
+[
+  {
+    "address": "src/app/main.py:lib",
+    "target_type": "python_source",
+    "dependencies": ["src/lib/utils.py:lib", "3rdparty/python#requests"],
+    "sources": ["src/app/main.py"],
+    "sources_fingerprint": "f1e2d3"
+  },
+  {
+    "address": "src/lib/utils.py:lib",
+    "target_type": "python_source",
+    "dependencies": [],
+    "sources": ["src/lib/utils.py"]
+  },
+  {
+    "address": "3rdparty/python#requests",
+    "target_type": "python_requirement",
+    "requirements": ["requests==2.32.3"]
+  }
+]
+`)
+
+func TestLoadPantsFile(t *testing.T) {
+	inputFormat = FormatPants
+	defer func() { inputFormat = "" }()
+
+	// output of the `dependencies` goal
+	adjacencyList, attributes, err := loadAdjacencyListWithAttributes("dg.json", []byte(`{"src/app/main.py:lib": ["src/lib/utils.py:lib"]}`))
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, AdjacencyList{"src/app/main.py:lib": {"src/lib/utils.py:lib"}}, adjacencyList)
+	assert.Nil(t, attributes)
+
+	// output of the `peek` goal
+	adjacencyList, attributes, err = loadAdjacencyListWithAttributes("peek.json", pantsPeekOutput)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, AdjacencyList{
+		"src/app/main.py:lib":      {"src/lib/utils.py:lib", "3rdparty/python#requests"},
+		"src/lib/utils.py:lib":     {},
+		"3rdparty/python#requests": {},
+	}, adjacencyList)
+	assert.Equal(t, NodeAttributes{
+		"src/app/main.py:lib": {
+			AttributeTargetType: "python_source",
+			AttributeSources:    []string{"src/app/main.py"},
+		},
+		"src/lib/utils.py:lib": {
+			AttributeTargetType: "python_source",
+			AttributeSources:    []string{"src/lib/utils.py"},
+		},
+		"3rdparty/python#requests": {
+			AttributeTargetType: "python_requirement",
+		},
+	}, attributes)
+}
+
+func TestLoadPantsPeekFileDetected(t *testing.T) {
+	// the output of the `peek` goal saved as JSON is loaded without setting the format
+	adjacencyList, attributes, err := loadAdjacencyListWithAttributes("peek.json", pantsPeekOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"src/lib/utils.py:lib", "3rdparty/python#requests"}, adjacencyList["src/app/main.py:lib"])
+	assert.Equal(t, "python_source", attributes["src/lib/utils.py:lib"][AttributeTargetType])
+}
+
+func TestAttributes(t *testing.T) {
+	inputFormat = FormatPants
+	defer func() { inputFormat = "" }()
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return pantsPeekOutput, nil
+	}
+	result, err := attributes("peek.json", []string{"3rdparty/python#requests", "missing.py"}, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, NodeAttributes{
+		"3rdparty/python#requests": {AttributeTargetType: "python_requirement"},
+	}, result)
+
+	result, err = attributes("peek.json", []string{}, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Len(t, result, 3)
+
+	// the adjacency list can be used by every command
+	deps, err := dependencies("peek.json", []string{"src/app/main.py:lib"}, true, false, 0, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, []string{"3rdparty/python#requests", "src/lib/utils.py:lib"}, deps)
+}
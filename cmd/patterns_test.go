@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternsInCommands(t *testing.T) {
+	input := []byte(`
+	{
+		"src/app/main.py": ["src/lib/a.py", "src/lib/b.py"],
+		"src/app/cli.py": ["src/lib/b.py"],
+		"src/lib/b.py": ["src/lib/c.py"]
+	}
+	`)
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return input, nil
+	}
+
+	deps, err := dependencies("mock.json", []string{"src/app/*"}, false, false, 0, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, []string{"src/lib/a.py", "src/lib/b.py"}, deps)
+
+	rdeps, err := dependents("mock.json", "", []string{"re:src/lib/[bc]\\.py"}, true, false, 0, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, []string{"src/app/cli.py", "src/app/main.py", "src/lib/b.py"}, rdeps)
+
+	result, err := paths("mock.json", "src/app/**", "src/lib/c.py", 0, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.ElementsMatch(t, [][]string{
+		{"src/app/cli.py", "src/lib/b.py", "src/lib/c.py"},
+		{"src/app/main.py", "src/lib/b.py", "src/lib/c.py"},
+	}, result)
+
+	subgraph, err := extractSubgraph("mock.json", "src/app/*", MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, AdjacencyList{
+		"src/app/main.py": {"src/lib/a.py", "src/lib/b.py"},
+		"src/app/cli.py":  {"src/lib/b.py"},
+		"src/lib/b.py":    {"src/lib/c.py"},
+	}, subgraph)
+
+	_, err = dependencies("mock.json", []string{"src/tests/**"}, false, false, 0, MockReadFile)
+	assert.EqualError(t, err, `pattern "src/tests/**" does not match any node`)
+}
+
+func TestNodesLookingLikePatternsInCommands(t *testing.T) {
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return []byte(`{"pages/[id].tsx": ["lib/api.ts"], "pages/i.tsx": ["lib/ui.ts"]}`), nil
+	}
+	deps, err := dependencies("mock.json", []string{"pages/[id].tsx"}, false, false, 0, MockReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"lib/api.ts"}, deps)
+}
@@ -0,0 +1,18 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+
+// to be used in non-unit tests
+var Query = query
+
+// query evaluates a query expression against the dependency graph (see dggraph.Query for the language)
+func query(filePath string, expression string, readFile ReadFileFunc) ([]string, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, err
+	}
+	return dggraph.Query(graph, expression, nil)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseQuery struct {
+	expression string
+	expected   []string
+}
+
+var queryInput = []byte(`
+{
+	"//app:main": ["//app:lib", "//lib/db:db"],
+	"//app:lib": ["//lib/db:db", "//third_party:json"],
+	"//lib/db:db": ["//lib/db:driver", "//third_party:sql"],
+	"//lib/db:driver": ["//third_party:sql"],
+	"//tools:gen": ["//lib/db:db"],
+	"//lib/cycle:a": ["//lib/cycle:b"],
+	"//lib/cycle:b": ["//lib/cycle:a"]
+}
+`)
+
+func TestQuery(t *testing.T) {
+	cases := []testCaseQuery{
+		{
+			expression: `//app:main`,
+			expected:   []string{"//app:main"},
+		},
+		{
+			expression: `deps(//app:lib)`,
+			expected:   []string{"//app:lib", "//lib/db:db", "//lib/db:driver", "//third_party:json", "//third_party:sql"},
+		},
+		{
+			expression: `deps(//app:lib, 1)`,
+			expected:   []string{"//app:lib", "//lib/db:db", "//third_party:json"},
+		},
+		{
+			expression: `deps(//app:lib, 0)`,
+			expected:   []string{"//app:lib"},
+		},
+		{
+			expression: `rdeps(deps(//app:main), //lib/db:db)`,
+			expected:   []string{"//app:lib", "//app:main", "//lib/db:db"},
+		},
+		{
+			expression: `rdeps(deps(//app:main) + //tools:gen, //lib/db:db, 1)`,
+			expected:   []string{"//app:lib", "//app:main", "//lib/db:db", "//tools:gen"},
+		},
+		{
+			expression: `allpaths(//app:main, //third_party:sql)`,
+			expected:   []string{"//app:lib", "//app:main", "//lib/db:db", "//lib/db:driver", "//third_party:sql"},
+		},
+		{
+			expression: `somepath(//app:main, //third_party:sql)`,
+			expected:   []string{"//app:main", "//lib/db:db", "//third_party:sql"},
+		},
+		{
+			expression: `somepath(//third_party:sql, //app:main)`,
+			expected:   []string{},
+		},
+		{
+			expression: `roots(deps(//app:main) union deps(//tools:gen))`,
+			expected:   []string{"//app:main", "//tools:gen"},
+		},
+		{
+			expression: `leaves(deps(//app:main))`,
+			expected:   []string{"//third_party:json", "//third_party:sql"},
+		},
+		// nodes in a cycle always have dependents and dependencies
+		{
+			expression: `roots(deps(//lib/cycle:a)) + leaves(deps(//lib/cycle:a))`,
+			expected:   []string{},
+		},
+		// transitive deps of the app that are also rdeps of the db library but not third-party
+		{
+			expression: `deps(//app:main) intersect rdeps(deps(//app:main), //lib/db:driver) except //app:main`,
+			expected:   []string{"//app:lib", "//lib/db:db", "//lib/db:driver"},
+		},
+		{
+			expression: `deps(//app:main) ^ deps(//tools:gen) - deps(//lib/db:driver)`,
+			expected:   []string{"//lib/db:db"},
+		},
+		{
+			expression: `let app = deps(//app:main) in let db = deps(//lib/db:db) in $app - $db`,
+			expected:   []string{"//app:lib", "//app:main", "//third_party:json"},
+		},
+		// quoted words are never treated as operators
+		{
+			expression: `"except" + 'let'`,
+			expected:   []string{"except", "let"},
+		},
+		{
+			expression: `(//app:main + //app:lib) ^ //app:lib`,
+			expected:   []string{"//app:lib"},
+		},
+	}
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return queryInput, nil
+	}
+	for _, testCase := range cases {
+		result, err := query("mock.json", testCase.expression, MockReadFile)
+		if err != nil {
+			t.Fatal(testCase.expression, err)
+		}
+		assert.Equal(t, testCase.expected, result, testCase.expression)
+	}
+}
+
+func TestQueryInvalid(t *testing.T) {
+	expressions := []string{
+		``,
+		`deps(`,
+		`deps(//app:main, -1)`,
+		`deps(//app:main, depth)`,
+		`rdeps(//app:main)`,
+		`//app:main +`,
+		`$undefined`,
+		`let = //app:main in $x`,
+		`let x = //app:main $x`,
+		`"unterminated`,
+		`//app:main )`,
+	}
+
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return queryInput, nil
+	}
+	for _, expression := range expressions {
+		_, err := query("mock.json", expression, MockReadFile)
+		assert.Error(t, err, expression)
+	}
+}
@@ -0,0 +1,355 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+// Cycles is a list of cycles where the last node of every cycle depends on the first one
+type Cycles [][]string
+
+// renderOptions control how graph-shaped results are rendered as DOT or Mermaid
+type renderOptions struct {
+	// nodes to highlight, e.g. targets of the query which can be patterns
+	highlight []string
+	// color edges that are part of a cycle
+	colorCycles bool
+	// group nodes by the directory prefix of this many path segments (0 disables clustering)
+	clusterDepth int
+	// put nodes at the same distance from the roots of the graph on the same rank (DOT only)
+	rankByDepth bool
+}
+
+// newRenderOptions creates the render options from the command-line flags
+func newRenderOptions(targets []string) renderOptions {
+	return renderOptions{
+		highlight:    append(slices.Clone(targets), highlightNodes...),
+		colorCycles:  colorCycles,
+		clusterDepth: clusterDepth,
+		rankByDepth:  rankByDepth,
+	}
+}
+
+// highlighted gets the nodes to highlight among given nodes expanding the targets that are patterns
+func (options renderOptions) highlighted(nodes []string) map[string]bool {
+	result := make(map[string]bool)
+	for _, target := range options.highlight {
+		// a target may not be rendered at all, e.g. a pattern matching only nodes outside of the result
+		matched, _ := dggraph.ExpandPatterns(nodes, []string{target})
+		for _, node := range matched {
+			result[node] = true
+		}
+	}
+	return result
+}
+
+/*
+Convert a result into a graph to be rendered: lists of nodes become isolated nodes and
+every group of nodes (e.g. a path) becomes a chain of edges; cycles are closed with
+an edge from the last node of a cycle to the first one, edges breaking cycles and violating rules become edges,
+and affected nodes depend on the nodes their reasons go through.
+*/
+func resultGraph(result any) (AdjacencyList, error) {
+	switch value := result.(type) {
+	case AdjacencyList:
+		return value, nil
+	case []string:
+		graph := make(AdjacencyList)
+		for _, node := range value {
+			graph.AddNode(node)
+		}
+		return graph, nil
+	case [][]string:
+		graph := make(AdjacencyList)
+		for _, group := range value {
+			for i, node := range group {
+				graph.AddNode(node)
+				if i > 0 {
+					graph.AddEdge(group[i-1], node)
+				}
+			}
+		}
+		return graph, nil
+	case Cycles:
+		graph, _ := resultGraph([][]string(value))
+		for _, cycle := range value {
+			if len(cycle) > 0 {
+				graph.AddEdge(cycle[len(cycle)-1], cycle[0])
+			}
+		}
+		return graph, nil
+	case CycleBreaks:
+		graph := make(AdjacencyList)
+		for _, edge := range value {
+			graph.AddEdge(edge.From, edge.To)
+		}
+		return graph, nil
+	case Violations:
+		graph := make(AdjacencyList)
+		for _, violation := range value {
+			if violation.To == "" {
+				graph.AddNode(violation.From)
+			} else {
+				graph.AddEdge(violation.From, violation.To)
+			}
+		}
+		return graph, nil
+	case Affected:
+		// every node depends on the previous node of its reason
+		graph := make(AdjacencyList)
+		for _, node := range value {
+			graph.AddNode(node.Node)
+			for i := 1; i < len(node.Reason); i++ {
+				graph.AddEdge(node.Reason[i], node.Reason[i-1])
+			}
+		}
+		return graph, nil
+	}
+	return nil, fmt.Errorf("result of type %T cannot be rendered as a graph", result)
+}
+
+// graphEdge is a dependency of a node on another node
+type graphEdge struct {
+	from string
+	to   string
+}
+
+// graphEdges lists all edges of a graph in a stable order
+func graphEdges(graph AdjacencyList) []graphEdge {
+	edges := []graphEdge{}
+	for _, node := range sortedKeys(graph) {
+		for _, dep := range graph[node] {
+			edges = append(edges, graphEdge{node, dep})
+		}
+	}
+	return edges
+}
+
+// cycleEdges finds edges that are part of a cycle, i.e. both nodes of the edge are in the same strongly connected component
+func cycleEdges(graph AdjacencyList) map[graphEdge]bool {
+	componentOf := make(map[string]int)
+	for i, component := range dggraph.StronglyConnectedComponents(dggraph.NewGraph(graph)) {
+		for _, node := range component {
+			componentOf[node] = i
+		}
+	}
+	result := make(map[graphEdge]bool)
+	for _, edge := range graphEdges(graph) {
+		if componentOf[edge.from] == componentOf[edge.to] {
+			result[edge] = true
+		}
+	}
+	return result
+}
+
+/*
+Get the cluster of a node which is the directory prefix of given depth, e.g. "src/lib"
+for "src/lib/db/models.py" or "//src/lib/db:models" with depth of 2.
+*/
+func nodeCluster(node string, depth int) string {
+	name, _, _ := strings.Cut(node, ":")
+	// Bazel labels refer to packages while other nodes are usually file paths
+	if strings.HasPrefix(name, "//") {
+		name = strings.TrimPrefix(name, "//")
+	} else {
+		name = path.Dir(name)
+	}
+	if name == "." || name == "" {
+		return ""
+	}
+	segments := strings.Split(name, "/")
+	if len(segments) > depth {
+		segments = segments[:depth]
+	}
+	return strings.Join(segments, "/")
+}
+
+// nodeClusters groups nodes by their clusters; nodes without a cluster are not included
+func nodeClusters(nodes []string, depth int) map[string][]string {
+	clusters := make(map[string][]string)
+	if depth <= 0 {
+		return clusters
+	}
+	for _, node := range nodes {
+		if cluster := nodeCluster(node, depth); cluster != "" {
+			clusters[cluster] = append(clusters[cluster], node)
+		}
+	}
+	return clusters
+}
+
+/*
+Get the depth of every node which is the shortest distance from any root of the graph;
+nodes that cannot be reached from a root (being part of a cycle) start a new search.
+*/
+func nodeDepths(graph AdjacencyList) map[string]int {
+	indexed := dggraph.NewGraph(graph)
+	depths := make([]int, indexed.Size())
+	visited := make([]bool, indexed.Size())
+	search := func(queue []int) {
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			for _, dep := range indexed.Dependencies(node) {
+				if !visited[dep] {
+					visited[dep] = true
+					depths[dep] = depths[node] + 1
+					queue = append(queue, dep)
+				}
+			}
+		}
+	}
+
+	roots := []int{}
+	for node := range indexed.Size() {
+		if len(indexed.Dependents(node)) == 0 {
+			roots = append(roots, node)
+			visited[node] = true
+		}
+	}
+	search(roots)
+	for node := range indexed.Size() {
+		if !visited[node] {
+			visited[node] = true
+			search([]int{node})
+		}
+	}
+
+	result := make(map[string]int, indexed.Size())
+	for node, depth := range depths {
+		result[indexed.Name(node)] = depth
+	}
+	return result
+}
+
+// dotQuote quotes a DOT identifier escaping backslashes first so that escaped quotes are not escaped again
+func dotQuote(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// renderDot renders a graph as a Graphviz DOT digraph
+func renderDot(graph AdjacencyList, options renderOptions) []byte {
+	var output bytes.Buffer
+	output.WriteString("digraph dg {\n")
+	nodes := graph.Nodes()
+
+	clusters := nodeClusters(nodes, options.clusterDepth)
+	for i, cluster := range sortedKeys(clusters) {
+		fmt.Fprintf(&output, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(cluster))
+		for _, node := range clusters[cluster] {
+			fmt.Fprintf(&output, "    %s;\n", dotQuote(node))
+		}
+		output.WriteString("  }\n")
+	}
+
+	if options.rankByDepth {
+		ranks := make(map[int][]string)
+		maxDepth := 0
+		for node, depth := range nodeDepths(graph) {
+			ranks[depth] = append(ranks[depth], node)
+			maxDepth = max(maxDepth, depth)
+		}
+		for depth := 0; depth <= maxDepth; depth++ {
+			slices.Sort(ranks[depth])
+			quoted := make([]string, 0, len(ranks[depth]))
+			for _, node := range ranks[depth] {
+				quoted = append(quoted, dotQuote(node))
+			}
+			fmt.Fprintf(&output, "  { rank=same; %s; }\n", strings.Join(quoted, "; "))
+		}
+	}
+
+	highlighted := options.highlighted(nodes)
+	for _, node := range nodes {
+		if highlighted[node] {
+			fmt.Fprintf(&output, "  %s [style=filled, fillcolor=lightblue];\n", dotQuote(node))
+		} else if len(graph[node]) == 0 {
+			fmt.Fprintf(&output, "  %s;\n", dotQuote(node))
+		}
+	}
+
+	var inCycle map[graphEdge]bool
+	if options.colorCycles {
+		inCycle = cycleEdges(graph)
+	}
+	for _, edge := range graphEdges(graph) {
+		if inCycle[edge] {
+			fmt.Fprintf(&output, "  %s -> %s [color=red];\n", dotQuote(edge.from), dotQuote(edge.to))
+		} else {
+			fmt.Fprintf(&output, "  %s -> %s;\n", dotQuote(edge.from), dotQuote(edge.to))
+		}
+	}
+	output.WriteString("}\n")
+	return output.Bytes()
+}
+
+/*
+Render a graph as a Mermaid flowchart. Mermaid has no control over node ranks
+so nodes are not ranked by depth.
+*/
+func renderMermaid(graph AdjacencyList, options renderOptions) []byte {
+	// Mermaid node IDs cannot contain most punctuation so labels are declared separately
+	ids := make(map[string]string)
+	label := func(value string) string {
+		return `"` + strings.ReplaceAll(value, `"`, "#quot;") + `"`
+	}
+	var output bytes.Buffer
+	output.WriteString("flowchart TD\n")
+	nodes := graph.Nodes()
+	for i, node := range nodes {
+		ids[node] = fmt.Sprintf("n%d", i)
+	}
+
+	clusters := nodeClusters(nodes, options.clusterDepth)
+	clustered := make(map[string]bool)
+	for i, cluster := range sortedKeys(clusters) {
+		fmt.Fprintf(&output, "  subgraph c%d [%s]\n", i, label(cluster))
+		for _, node := range clusters[cluster] {
+			fmt.Fprintf(&output, "    %s[%s]\n", ids[node], label(node))
+			clustered[node] = true
+		}
+		output.WriteString("  end\n")
+	}
+	for _, node := range nodes {
+		if !clustered[node] {
+			fmt.Fprintf(&output, "  %s[%s]\n", ids[node], label(node))
+		}
+	}
+
+	var inCycle map[graphEdge]bool
+	if options.colorCycles {
+		inCycle = cycleEdges(graph)
+	}
+	cycleLinks := []string{}
+	for i, edge := range graphEdges(graph) {
+		fmt.Fprintf(&output, "  %s --> %s\n", ids[edge.from], ids[edge.to])
+		if inCycle[edge] {
+			cycleLinks = append(cycleLinks, fmt.Sprint(i))
+		}
+	}
+	if len(cycleLinks) > 0 {
+		fmt.Fprintf(&output, "  linkStyle %s stroke:red\n", strings.Join(cycleLinks, ","))
+	}
+
+	highlighted := []string{}
+	isHighlighted := options.highlighted(nodes)
+	for _, node := range nodes {
+		if isHighlighted[node] {
+			highlighted = append(highlighted, ids[node])
+		}
+	}
+	if len(highlighted) > 0 {
+		output.WriteString("  classDef highlighted fill:lightblue\n")
+		fmt.Fprintf(&output, "  class %s highlighted\n", strings.Join(highlighted, ","))
+	}
+	return output.Bytes()
+}
@@ -0,0 +1,118 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeCluster(t *testing.T) {
+	cases := map[string]string{
+		"src/lib/db/models.py":  "src/lib",
+		"src/lib/utils.py":      "src/lib",
+		"src/app.py":            "src",
+		"main.py":               "",
+		"//src/lib/db:models":   "src/lib",
+		"//lib:util":            "lib",
+		"//:root":               "",
+		"src/app/main.py:lib":   "src/app",
+		"3rdparty/python#numpy": "3rdparty",
+	}
+	for node, expected := range cases {
+		assert.Equal(t, expected, nodeCluster(node, 2), node)
+	}
+}
+
+func TestNodeDepths(t *testing.T) {
+	graph := AdjacencyList{
+		"a": {"b", "c"},
+		"b": {"c"},
+		// a cycle not reachable from any root
+		"x": {"y"},
+		"y": {"x"},
+	}
+	assert.Equal(t, map[string]int{"a": 0, "b": 1, "c": 1, "x": 0, "y": 1}, nodeDepths(graph))
+}
+
+func TestRenderDot(t *testing.T) {
+	graph := AdjacencyList{
+		"src/app/main.py": {"src/lib/db.py"},
+		"src/lib/db.py":   {"src/lib/orm.py"},
+		"src/lib/orm.py":  {"src/lib/db.py"},
+	}
+	options := renderOptions{
+		highlight:    []string{"src/app/main.py"},
+		colorCycles:  true,
+		clusterDepth: 2,
+		rankByDepth:  true,
+	}
+	expected := `digraph dg {
+  subgraph cluster_0 {
+    label="src/app";
+    "src/app/main.py";
+  }
+  subgraph cluster_1 {
+    label="src/lib";
+    "src/lib/db.py";
+    "src/lib/orm.py";
+  }
+  { rank=same; "src/app/main.py"; }
+  { rank=same; "src/lib/db.py"; }
+  { rank=same; "src/lib/orm.py"; }
+  "src/app/main.py" [style=filled, fillcolor=lightblue];
+  "src/app/main.py" -> "src/lib/db.py";
+  "src/lib/db.py" -> "src/lib/orm.py" [color=red];
+  "src/lib/orm.py" -> "src/lib/db.py" [color=red];
+}
+`
+	assert.Equal(t, expected, string(renderDot(graph, options)))
+}
+
+func TestRenderMermaid(t *testing.T) {
+	graph, err := resultGraph(Cycles{{"a/x.py", "a/y.py"}})
+	if err != nil {
+		t.Fail()
+	}
+	graph["main.py"] = []string{"a/x.py"}
+	options := renderOptions{
+		// patterns are highlighted by the nodes they match
+		highlight:    []string{"re:main\\..*"},
+		colorCycles:  true,
+		clusterDepth: 1,
+	}
+	expected := `flowchart TD
+  subgraph c0 ["a"]
+    n0["a/x.py"]
+    n1["a/y.py"]
+  end
+  n2["main.py"]
+  n0 --> n1
+  n1 --> n0
+  n2 --> n0
+  linkStyle 0,1 stroke:red
+  classDef highlighted fill:lightblue
+  class n2 highlighted
+`
+	assert.Equal(t, expected, string(renderMermaid(graph, options)))
+}
+
+func TestDotQuote(t *testing.T) {
+	assert.Equal(t, `"//src:lib"`, dotQuote("//src:lib"))
+	assert.Equal(t, `"say \"hi\""`, dotQuote(`say "hi"`))
+	// a backslash is escaped before the quote so it cannot end the string
+	assert.Equal(t, `"dir\\\""`, dotQuote(`dir\"`))
+}
+
+func TestCycleEdges(t *testing.T) {
+	graph := AdjacencyList{
+		"a": {"b", "a"},
+		"b": {"c"},
+		"c": {"b", "d"},
+		"d": {},
+	}
+	expected := map[graphEdge]bool{{"a", "a"}: true, {"b", "c"}: true, {"c", "b"}: true}
+	assert.Equal(t, expected, cycleEdges(graph))
+}
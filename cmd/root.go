@@ -4,11 +4,13 @@ Copyright © 2024 Alexey Tereshenkov
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
+	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
 	"github.com/spf13/cobra"
 )
 
@@ -22,7 +24,8 @@ var RootCmd = &cobra.Command{
 	Use:   "dg-query",
 	Short: "A command-line utility program to query dependency graph of a codebase.",
 	Long: `A command-line utility program to query dependency graph of a codebase
-which operates on the adjacency list data stored as a JSON file. 
+which operates on the adjacency list data stored as a JSON file
+(or as a DOT, CSV/TSV, GraphML, or JSON Lines file). 
 
 Git revision: ` + Version,
 }
@@ -37,18 +40,17 @@ var dependenciesCmd = &cobra.Command{
 	Args: cobra.MinimumNArgs(1),
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePath, _ := cmd.Flags().GetString("dg")
-		transitive, _ := cmd.Flags().GetBool("transitive")
-		reflexive, _ := cmd.Flags().GetBool("reflexive")
-		depth, _ := cmd.Flags().GetInt("depth")
+		options := flagOptions(cmd).dependencyOptions()
 
-		result, err := dependencies(filePath, targets, transitive, reflexive, depth, DefaultReadFile)
+		result, err := dependencies(filePath, targets, options.Transitive, options.Reflexive, options.Depth, DefaultReadFile)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		output := strings.Join(result, "\n")
-		cmd.OutOrStdout().Write([]byte(output))
-		cmd.OutOrStdout().Write([]byte("\n"))
+		if err := writeOutput(cmd, result, OutputText, targets...); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -65,9 +67,10 @@ var rootsCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		output := strings.Join(result, "\n")
-		cmd.OutOrStdout().Write([]byte(output))
-		cmd.OutOrStdout().Write([]byte("\n"))
+		if err := writeOutput(cmd, result, OutputText); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -84,9 +87,40 @@ var leavesCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		output := strings.Join(result, "\n")
-		cmd.OutOrStdout().Write([]byte(output))
-		cmd.OutOrStdout().Write([]byte("\n"))
+		if err := writeOutput(cmd, result, OutputText); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var toposortCmd = &cobra.Command{
+	Use:   "toposort",
+	Short: "Order nodes so that every node comes after its dependencies",
+	Long: `Order nodes so that every node comes after its dependencies (i.e. in a build order). With --layers,
+nodes are grouped into layers that can be built in parallel: the leaves first, then the nodes that only depend
+on the leaves, and so on. The command fails listing the strongly connected components with cycles if the
+dependency graph has cycles.`,
+	Run: func(cmd *cobra.Command, targets []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		layers := flagOptions(cmd).bool("layers")
+		var result any
+		var err error
+		defaultFormat := OutputText
+		if layers {
+			result, err = buildLayers(filePath, DefaultReadFile)
+			defaultFormat = OutputJson
+		} else {
+			result, err = toposort(filePath, DefaultReadFile)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeOutput(cmd, result, defaultFormat); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -101,38 +135,212 @@ var dependentsCmd = &cobra.Command{
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePathDg, _ := cmd.Flags().GetString("dg")
 		filePathDgReverse, _ := cmd.Flags().GetString("rdg")
-		transitive, _ := cmd.Flags().GetBool("transitive")
-		reflexive, _ := cmd.Flags().GetBool("reflexive")
-		depth, _ := cmd.Flags().GetInt("depth")
+		options := flagOptions(cmd).dependencyOptions()
 
 		result, err := dependents(filePathDg, filePathDgReverse,
-			targets, transitive, reflexive, depth, DefaultReadFile)
+			targets, options.Transitive, options.Reflexive, options.Depth, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeOutput(cmd, result, OutputText, targets...); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var affectedCmd = &cobra.Command{
+	Use:   "affected",
+	Short: "Get nodes affected by changed files",
+	Long: `Get nodes affected by changed files, i.e. the nodes of the changed files and their transitive dependents,
+along with the chain of dependents from a changed node to every affected node (listed in JSON and CSV output).
+Changed files are passed with --changed-files ("-" to read them from stdin, one per line) or listed with
+"git diff --name-only <base>...HEAD" when --base is set. A file is mapped to the node named after it or,
+with --owners, to the nodes owning the longest prefix of its path (skipping owners not in the dependency graph);
+files not mapped to any node are reported.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePathDg, _ := cmd.Flags().GetString("dg")
+		filePathDgReverse, _ := cmd.Flags().GetString("rdg")
+		files, _ := cmd.Flags().GetStringSlice("changed-files")
+		base, _ := cmd.Flags().GetString("base")
+		filePathOwners, _ := cmd.Flags().GetString("owners")
+
+		files, err := changedFiles(files, base, filePathDg, DefaultReadFile)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		output := strings.Join(result, "\n")
-		cmd.OutOrStdout().Write([]byte(output))
-		cmd.OutOrStdout().Write([]byte("\n"))
+		result, unmapped, err := affected(filePathDg, filePathDgReverse, files, filePathOwners, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(unmapped) > 0 {
+			log.Printf("changed files not mapped to any node: %s", strings.Join(unmapped, ", "))
+		}
+		if err := writeOutput(cmd, result, OutputText); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var testsForCmd = &cobra.Command{
+	Use:   "tests-for [targets]",
+	Short: "Get tests affected by changed nodes or files",
+	Long: `Get tests affected by changed nodes (given targets) or changed files (passed as for the affected command),
+i.e. the affected nodes matching any of the --test-pattern patterns (e.g. "re:.*_test\.py" or "//...:*_test")
+and having the --test-attribute attribute (e.g. "target_type=python_tests" for Pants or "kind=py_test" for Bazel).
+With --by-distance, the tests closest to the changes come first.`,
+	Run: func(cmd *cobra.Command, targets []string) {
+		filePathDg, _ := cmd.Flags().GetString("dg")
+		files, _ := cmd.Flags().GetStringSlice("changed-files")
+		base, _ := cmd.Flags().GetString("base")
+		filePathOwners, _ := cmd.Flags().GetString("owners")
+		byDistance, _ := cmd.Flags().GetBool("by-distance")
+		var selector dggraph.TestSelector
+		selector.Patterns, _ = cmd.Flags().GetStringSlice("test-pattern")
+		attribute, _ := cmd.Flags().GetString("test-attribute")
+
+		if len(selector.Patterns) == 0 && attribute == "" {
+			fmt.Println("tests must be selected with --test-pattern or --test-attribute")
+			os.Exit(1)
+		}
+		if attribute != "" {
+			var found bool
+			if selector.Attribute, selector.Value, found = strings.Cut(attribute, "="); !found {
+				fmt.Printf("invalid test attribute %q: must be of the form name=value\n", attribute)
+				os.Exit(1)
+			}
+		}
+		if len(targets) == 0 || len(files) > 0 || base != "" {
+			var err error
+			if files, err = changedFiles(files, base, filePathDg, DefaultReadFile); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		result, unmapped, err := testsFor(filePathDg, targets, files, filePathOwners, selector, byDistance, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(unmapped) > 0 {
+			log.Printf("changed files not mapped to any node: %s", strings.Join(unmapped, ", "))
+		}
+		if err := writeOutput(cmd, result, OutputText, targets...); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare two snapshots of the dependency graph",
+	Long: `Compare two snapshots of the dependency graph (--old and --new): nodes and edges added and removed,
+nodes whose numbers of transitive dependencies changed the most, cycles introduced, and connected components
+merged or split. Changes are grouped in sections in text output (the default) and listed by kind otherwise.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePathOld, _ := cmd.Flags().GetString("old")
+		filePathNew, _ := cmd.Flags().GetString("new")
+		reader := flagOptions(cmd)
+		options := dggraph.DiffOptions{Top: reader.int("top"), Cycles: reader.cycleOptions()}
+		if reader.err != nil {
+			fmt.Println(reader.err)
+			os.Exit(1)
+		}
+
+		result, err := diff(filePathOld, filePathNew, options, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if !result.CyclesComplete {
+			log.Println(incompleteCyclesWarning)
+		}
+		if err := writeOutput(cmd, result, OutputText); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check the dependency graph against dependency rules",
+	Long: `Check the dependency graph against the dependency rules of a JSON file (--rules) which defines layers
+of nodes with target patterns and rules between the layers: forbidden and allowed dependencies, maximum fan-in
+and fan-out, no cycles, and maximum depth. Every dependency (or node) violating a rule is reported with the rule
+and the command exits with a non-zero status if there are any violations.
+
+With --baseline, only the violations not recorded in the baseline file are reported and fail the command;
+with --update-baseline, the violations fixed since are removed from the baseline (which is created
+with the current violations if it doesn't exist), so the baseline can only shrink.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		filePathRules, _ := cmd.Flags().GetString("rules")
+		if filePathRules == "" {
+			fmt.Println("rules file must be set with --rules")
+			os.Exit(1)
+		}
+		baseline, err := getBaselineOptions(cmd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		result, err := check(filePath, filePathRules, DefaultReadFile)
+		if err == nil && baseline.filePath != "" {
+			result, err = violationsBaseline(result, baseline, DefaultReadFile)
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeOutput(cmd, result, OutputText); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if len(result) > 0 {
+			if baseline.filePath != "" {
+				log.Printf("found %d violations of dependency rules not in the baseline", len(result))
+			} else {
+				log.Printf("found %d violations of dependency rules", len(result))
+			}
+			os.Exit(1)
+		}
 	},
 }
 
 var metricsCmd = &cobra.Command{
 	Use:   "metrics",
 	Short: "Get dependency graph related metrics",
-	Long:  `Get dependency graph related metrics`,
+	Long: `Get dependency graph related metrics. With --table (implied by --top, --sort-by, --min, and --max),
+the metrics of every node are joined in a row of a table sorted by a metric, the largest values first.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		filePathDg, _ := cmd.Flags().GetString("dg")
 		filePathDgReverse, _ := cmd.Flags().GetString("rdg")
-		metricsItems, _ := cmd.Flags().GetStringSlice("metric")
-		result, err := metrics(filePathDg, filePathDgReverse, metricsItems, DefaultReadFile)
+		reader := flagOptions(cmd)
+		metricsItems, options := reader.metrics()
+		if reader.err != nil {
+			fmt.Println(reader.err)
+			os.Exit(1)
+		}
+		result, err := metricsResult(filePathDg, filePathDgReverse, metricsItems, options, DefaultReadFile)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		cmd.OutOrStdout().Write(result)
-		cmd.OutOrStdout().Write([]byte("\n"))
-
+		// a table is meant to be read by people
+		defaultFormat := OutputJson
+		if options.tabular() {
+			defaultFormat = OutputText
+		}
+		if err := writeOutput(cmd, result, defaultFormat); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -142,73 +350,167 @@ var pathsCmd = &cobra.Command{
 	Long:  `Get paths between targets`,
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePath, _ := cmd.Flags().GetString("dg")
-		fromTarget, _ := cmd.Flags().GetString("from")
-		toTarget, _ := cmd.Flags().GetString("to")
-		maxPaths, _ := cmd.Flags().GetInt("n")
+		reader := flagOptions(cmd)
+		fromTarget, toTarget, maxPaths := reader.string("from"), reader.string("to"), reader.int("n")
+		if reader.err != nil {
+			fmt.Println(reader.err)
+			os.Exit(1)
+		}
 		result, err := paths(filePath, fromTarget, toTarget, maxPaths, DefaultReadFile)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		resultJson, _ := json.MarshalIndent(result, "", "  ")
-		cmd.OutOrStdout().Write(resultJson)
-		cmd.OutOrStdout().Write([]byte("\n"))
-
+		if err := writeOutput(cmd, result, OutputJson, fromTarget, toTarget); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
 var cyclesCmd = &cobra.Command{
 	Use:   "cycles",
 	Short: "Find cycles in the dependency graph",
-	Long:  `Find cycles in the dependency graph`,
+	Long: `Find cycles in the dependency graph. On large graphs with many cycles, the search can be limited
+to short cycles, cycles through given nodes, a number of cycles, or a time in which case the cycles found
+so far are listed.
+
+With --suggest-breaks, edges to remove to make the dependency graph acyclic are suggested instead, ranked
+by the number of cycles going through them; with --acyclic, the dependency graph without these edges is written.
+
+With --baseline, only the cycles not recorded in the baseline file are listed and the command fails if there are
+any; with --update-baseline, the cycles fixed since are removed from the baseline (which is created with
+the current cycles if it doesn't exist), so the baseline can only shrink. The baseline can only be updated
+when all cycles are searched for (not with --through or --max-length).`,
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePath, _ := cmd.Flags().GetString("dg")
-		result, err := cycles(filePath, DefaultReadFile)
+		reader := flagOptions(cmd)
+		options := reader.cycleOptions()
+		suggestBreaksFlag, acyclic := reader.bool("suggest-breaks"), reader.acyclic()
+		baseline, err := getBaselineOptions(cmd)
+		if err == nil {
+			err = reader.err
+		}
+		if err == nil && baseline.filePath != "" && (acyclic || suggestBreaksFlag) {
+			err = fmt.Errorf("--baseline can only be used to list cycles")
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		resultJson, _ := json.MarshalIndent(result, "", "  ")
-		cmd.OutOrStdout().Write(resultJson)
-		cmd.OutOrStdout().Write([]byte("\n"))
-
+		var result any
+		complete := true
+		switch {
+		case acyclic:
+			result, err = breakCycles(filePath, DefaultReadFile)
+		case suggestBreaksFlag:
+			result, complete, err = suggestBreaks(filePath, options, DefaultReadFile)
+		default:
+			var found [][]string
+			found, complete, err = cycles(filePath, options, DefaultReadFile)
+			result = Cycles(found)
+			if err == nil && baseline.filePath != "" {
+				result, err = cyclesBaseline(found, complete, options, baseline, DefaultReadFile)
+			}
+		}
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if !complete {
+			log.Println(incompleteCyclesWarning)
+		}
+		if err := writeOutput(cmd, result, OutputJson); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if added, isCycles := result.(Cycles); isCycles && baseline.filePath != "" && len(added) > 0 {
+			log.Printf("found %d cycles not in the baseline", len(added))
+			os.Exit(1)
+		}
 	},
 }
 
+// getBaselineOptions reads the baseline flags of a command
+func getBaselineOptions(cmd *cobra.Command) (baselineOptions, error) {
+	var options baselineOptions
+	options.filePath, _ = cmd.Flags().GetString("baseline")
+	options.update, _ = cmd.Flags().GetBool("update-baseline")
+	if options.update && options.filePath == "" {
+		return options, fmt.Errorf("--update-baseline requires --baseline")
+	}
+	return options, nil
+}
+
 var subgraphCmd = &cobra.Command{
 	Use:   "subgraph",
 	Short: "Extract a subgraph out of the dependency graph",
 	Long:  `Extract a subgraph out of the dependency graph`,
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePath, _ := cmd.Flags().GetString("dg")
-		rootNode, _ := cmd.Flags().GetString("root")
+		reader := flagOptions(cmd)
+		rootNode := reader.string("root")
+		if reader.err != nil {
+			fmt.Println(reader.err)
+			os.Exit(1)
+		}
 		result, err := extractSubgraph(filePath, rootNode, DefaultReadFile)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		resultJson, _ := json.MarshalIndent(result, "", "  ")
-		cmd.OutOrStdout().Write(resultJson)
-		cmd.OutOrStdout().Write([]byte("\n"))
-
+		if err := writeOutput(cmd, result, OutputJson, rootNode); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
 var componentsCmd = &cobra.Command{
 	Use:   "components",
 	Short: "Get a list of connected components in the dependency graph",
-	Long:  `Get a list of connected components in the dependency graph`,
+	Long: `Get a list of connected components in the dependency graph. With --strong, strongly connected
+components (nodes that can all reach each other through dependencies) are listed with their sizes,
+the largest first.`,
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePath, _ := cmd.Flags().GetString("dg")
-		result, err := listConnectedComponents(filePath, DefaultReadFile)
+		reader := flagOptions(cmd)
+		var result any
+		var err error
+		if reader.bool("strong") {
+			result, err = listStrongComponents(filePath, reader.int("min-size"), DefaultReadFile)
+		} else {
+			result, err = listConnectedComponents(filePath, DefaultReadFile)
+		}
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		resultJson, _ := json.MarshalIndent(result, "", "  ")
-		cmd.OutOrStdout().Write(resultJson)
-		cmd.OutOrStdout().Write([]byte("\n"))
+		if err := writeOutput(cmd, result, OutputJson); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
 
+var condenseCmd = &cobra.Command{
+	Use:   "condense",
+	Short: "Condense strongly connected components of the dependency graph into single nodes",
+	Long: `Condense strongly connected components of the dependency graph into single nodes producing
+an acyclic dependency graph that can be used as input to other commands. A component is named after
+its nodes joined with "|" (nodes that are not part of a cycle keep their names); if the name is already taken
+by a node or another component, the smallest free suffix "#2", "#3", etc. is added to it.`,
+	Run: func(cmd *cobra.Command, targets []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		result, err := condense(filePath, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeOutput(cmd, result, OutputJson); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -218,16 +520,131 @@ var simplifyCmd = &cobra.Command{
 	Long:  `Simplify the dependency graph by applying a requested technique`,
 	Run: func(cmd *cobra.Command, targets []string) {
 		filePath, _ := cmd.Flags().GetString("dg")
-		technique, _ := cmd.Flags().GetString("technique")
+		// an invalid technique is reported by simplifyAdjacencyList
+		technique := flagOptions(cmd).string("technique")
 		result, err := simplifyAdjacencyList(filePath, DefaultReadFile, technique)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		resultJson, _ := json.MarshalIndent(result, "", "  ")
-		cmd.OutOrStdout().Write(resultJson)
-		cmd.OutOrStdout().Write([]byte("\n"))
+		if err := writeOutput(cmd, result, OutputJson); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// getting attributes of given targets (if the input format provides them)
+var attributesCmd = &cobra.Command{
+	Use:   "attributes",
+	Short: "Get attributes of given targets such as target type and sources",
+	Long: `Get attributes of given targets such as target type and sources (or of all nodes if no targets are given);
+only some input formats, e.g. the output of the "pants peek" goal, provide node attributes`,
+	Run: func(cmd *cobra.Command, targets []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		result, err := attributes(filePath, targets, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeOutput(cmd, result, OutputJson); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
 
+// evaluating a query expression over the dependency graph
+var queryCmd = &cobra.Command{
+	Use:   "query <expression>",
+	Short: "Evaluate a query expression over the dependency graph",
+	Long: `Evaluate a query expression over the dependency graph, e.g.
+
+  dg-query query 'deps(//app) intersect rdeps(deps(//app), //lib/db) except //third_party:json'
+
+Supported expressions: deps(x[, depth]), rdeps(universe, x[, depth]), allpaths(a, b), somepath(a, b),
+roots(x), leaves(x), x intersect y (x ^ y), x union y (x + y), x except y (x - y), let v = x in $v`,
+	// requiring the query expression (which may be split into multiple arguments by the shell)
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		result, err := query(filePath, strings.Join(args, " "), DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if err := writeOutput(cmd, result, OutputText); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// writing the index of the dependency graph to be used instead of parsing the dependency graph file
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Write the index of the dependency graph for faster loading",
+	Long: `Write the index of the dependency graph, a binary file with the graph already parsed
+which is much faster to load than the dependency graph file. The index written next to the dependency
+graph file (e.g. dg.idx for dg.json) is used by all commands automatically as long as the dependency graph
+file has not changed; an index file can also be passed with the --dg flag directly.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		indexPath, _ := cmd.Flags().GetString("out")
+		result, err := index(filePath, indexPath, DefaultReadFile)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), result)
+	},
+}
+
+// serving queries over HTTP using the dependency graph loaded once
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve queries over HTTP with the dependency graph loaded once",
+	Long: `Serve queries over an HTTP JSON API with the dependency graph loaded once, e.g.
+
+  curl 'localhost:8080/dependencies?target=foo.py&transitive=true'
+
+Endpoints: /dependencies, /dependents, /paths, /cycles, /components, /condense, /subgraph, /simplify, /metrics,
+/roots, /leaves, /toposort, and /query with parameters named after the flags of the commands. The graph is reloaded
+when the dependency graph file changes. Searches of cycles are bounded by --timeout and --max-cycles which
+requests can only lower; the X-Search-Complete header of the response tells whether the search was stopped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		address, _ := cmd.Flags().GetString("listen")
+		reloadInterval, _ := cmd.Flags().GetDuration("reload-interval")
+		var limits serverLimits
+		limits.timeout, _ = cmd.Flags().GetDuration("timeout")
+		limits.maxCycles, _ = cmd.Flags().GetInt("max-cycles")
+		if err := serve(filePath, address, reloadInterval, limits, DefaultReadFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	},
+}
+
+// exploring the dependency graph interactively with the graph loaded once
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Explore the dependency graph interactively",
+	Long: `Explore the dependency graph interactively running commands as statements against the graph loaded once, e.g.
+
+  dg> app = deps --transitive src/app/main.py
+  dg> rdeps $app --reflexive
+  dg> paths --from=src/app/main.py --to=$last
+
+Node names, statements, flags, and variables are completed with Tab. The nodes of the last result are
+stored in $last and can be stored in other variables with assignments. Use :reload to load the dependency
+graph file again and :help to list all statements.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		filePath, _ := cmd.Flags().GetString("dg")
+		if err := runShell(filePath, DefaultReadFile); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
@@ -235,18 +652,20 @@ var simplifyCmd = &cobra.Command{
 var dg string
 var rdg string
 
-// metrics to be generated by the `metrics` command
-var metricsFlags []string
+// format of the dependency graph file; detected from the file extension if not set
+var inputFormat string
 
-// paths command from and to targets
-var fromTarget string
-var toTarget string
+// format of the command output; every command has its own default format
+var outputFormat string
 
-// subgraph command root node
-var rootNode string
+// options for rendering graph-shaped results as DOT or Mermaid
+var highlightNodes []string
+var colorCycles bool
+var clusterDepth int
+var rankByDepth bool
 
-// simplify command technique to apply
-var simplifyTechnique string
+// index command output file
+var indexOut string
 
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the RootCmd.
@@ -268,34 +687,70 @@ func init() {
 	RootCmd.AddCommand(subgraphCmd)
 	RootCmd.AddCommand(dependenciesCmd)
 	RootCmd.AddCommand(dependentsCmd)
+	RootCmd.AddCommand(affectedCmd)
+	RootCmd.AddCommand(testsForCmd)
+	RootCmd.AddCommand(diffCmd)
+	RootCmd.AddCommand(checkCmd)
 	RootCmd.AddCommand(componentsCmd)
+	RootCmd.AddCommand(condenseCmd)
 	RootCmd.AddCommand(rootsCmd)
 	RootCmd.AddCommand(leavesCmd)
+	RootCmd.AddCommand(toposortCmd)
 	RootCmd.AddCommand(simplifyCmd)
+	RootCmd.AddCommand(attributesCmd)
+	RootCmd.AddCommand(queryCmd)
+	RootCmd.AddCommand(indexCmd)
+	RootCmd.AddCommand(serveCmd)
+	RootCmd.AddCommand(shellCmd)
+
+	// the options of the queries are shared with the server and the shell
+	for _, command := range RootCmd.Commands() {
+		addOptionFlags(command)
+	}
 
 	//make dg flag global for all commands as all of them will need dg data
-	RootCmd.PersistentFlags().StringVar(&dg, "dg", "", "JSON file with the dependency graph represented as an adjacency list")
+	RootCmd.PersistentFlags().StringVar(&dg, "dg", "", "JSON file with the dependency graph represented as an adjacency list (\"-\" to read from stdin, gzip and zstd compressed files and index files are supported)")
+	RootCmd.PersistentFlags().StringVar(&outputFormat, "output", "", "Output format: "+strings.Join(allowedOutputs, ", ")+" (plain text for lists of nodes and JSON otherwise by default)")
+	RootCmd.PersistentFlags().StringSliceVar(&highlightNodes, "highlight", []string{}, "Nodes to highlight in DOT and Mermaid output in addition to the targets of the query")
+	RootCmd.PersistentFlags().BoolVar(&colorCycles, "color-cycles", false, "Color edges that are part of a cycle in DOT and Mermaid output")
+	RootCmd.PersistentFlags().IntVar(&clusterDepth, "cluster-depth", 0, "Cluster nodes by directory prefix of this many path segments in DOT and Mermaid output")
+	RootCmd.PersistentFlags().BoolVar(&rankByDepth, "rank", false, "Put nodes at the same depth from the roots on the same rank in DOT output")
+	RootCmd.PersistentFlags().StringVar(&inputFormat, "format", "", "Format of the dependency graph file: "+strings.Join(dggraph.Formats, ", ")+" (detected from the file extension by default)")
 
 	pathsCmd.Flags().StringVar(&dg, "dg", "", "JSON file with the dependency graph represented as an adjacency list")
-	pathsCmd.Flags().StringVar(&fromTarget, "from", "", "Find path from this target")
-	pathsCmd.Flags().StringVar(&toTarget, "to", "", "Find path to this target")
-	pathsCmd.Flags().Int("n", 0, "Only return first n paths between targets")
 
 	metricsCmd.Flags().StringVar(&rdg, "rdg", "", "JSON file with the dependency graph represented as an adjacency list")
-	metricsCmd.Flags().StringSliceVar(&metricsFlags, "metric", []string{}, "Metrics to report")
 
-	dependenciesCmd.Flags().BoolP("transitive", "", false, "Get transitive dependencies")
-	dependenciesCmd.Flags().BoolP("reflexive", "", false, "Include input targets in the output")
-	dependenciesCmd.Flags().Int("depth", 0, "Depth of search for transitive dependencies")
+	cyclesCmd.Flags().String("baseline", "", "JSON file with known cycles; only new cycles are listed and fail the command")
+	cyclesCmd.Flags().Bool("update-baseline", false, "Remove fixed cycles from the baseline (creating it if it doesn't exist)")
 
 	dependentsCmd.Flags().StringVar(&rdg, "rdg", "", "JSON file with the dependency graph represented as an adjacency list")
-	dependentsCmd.Flags().BoolP("transitive", "", false, "Get transitive dependents")
-	dependentsCmd.Flags().BoolP("reflexive", "", false, "Include input targets in the output")
-	dependentsCmd.Flags().Int("depth", 0, "Depth of search for transitive dependents")
 
-	subgraphCmd.Flags().StringVar(&rootNode, "root", "", "Root node for the subgraph to extract")
+	affectedCmd.Flags().StringVar(&rdg, "rdg", "", "JSON file with the dependency graph represented as an adjacency list")
+	affectedCmd.Flags().StringSlice("changed-files", []string{}, "Changed files (\"-\" to read them from stdin, one per line)")
+	affectedCmd.Flags().String("base", "", "Git revision to list the files changed since (as \"git diff --name-only <base>...HEAD\" does)")
+	affectedCmd.Flags().String("owners", "", "JSON file mapping path prefixes to the nodes owning the files with the prefix")
+
+	testsForCmd.Flags().StringSlice("changed-files", []string{}, "Changed files (\"-\" to read them from stdin, one per line)")
+	testsForCmd.Flags().String("base", "", "Git revision to list the files changed since (as \"git diff --name-only <base>...HEAD\" does)")
+	testsForCmd.Flags().String("owners", "", "JSON file mapping path prefixes to the nodes owning the files with the prefix")
+	testsForCmd.Flags().StringSlice("test-pattern", []string{}, "Patterns (or names) of test nodes")
+	testsForCmd.Flags().String("test-attribute", "", "Attribute of test nodes as name=value (a list attribute must contain the value)")
+	testsForCmd.Flags().Bool("by-distance", false, "Sort tests by the distance from the changes, the closest first")
+
+	diffCmd.Flags().String("old", "", "File with the old dependency graph")
+	diffCmd.Flags().String("new", "", "File with the new dependency graph")
+
+	checkCmd.Flags().String("rules", "", "JSON file with the dependency rules")
+	checkCmd.Flags().String("baseline", "", "JSON file with known violations; only new violations are reported")
+	checkCmd.Flags().Bool("update-baseline", false, "Remove fixed violations from the baseline (creating it if it doesn't exist)")
+
+	indexCmd.Flags().StringVar(&indexOut, "out", "", "Index file to write (next to the dependency graph file with the .idx extension by default)")
 
-	simplifyCmd.Flags().StringVar(&simplifyTechnique, "technique", "", "Technique to simplify the dependency graph")
+	serveCmd.Flags().String("listen", "localhost:8080", "Address to listen on")
+	serveCmd.Flags().Duration("reload-interval", 2*time.Second, "How often to check the dependency graph file for changes (0 disables reloading)")
+	serveCmd.Flags().Duration("timeout", 10*time.Second, "Maximum time of a search of cycles of a request (0 disables the limit)")
+	serveCmd.Flags().Int("max-cycles", 10000, "Maximum number of cycles found for a request (0 disables the limit)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
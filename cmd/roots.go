@@ -3,37 +3,16 @@ Copyright © 2024 Alexey Tereshenkov
 */
 package cmd
 
-import "sort"
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
 
 // to be used in non-unit tests
 var Roots = roots
 
 // roots returns nodes (aka sources) that no other node depends on
 func roots(filePath string, readFile ReadFileFunc) ([]string, error) {
-	jsonData, err := readFile(filePath)
+	graph, err := loadGraph(filePath, readFile)
 	if err != nil {
 		return nil, err
 	}
-	adjacencyList, err := loadJsonFile(jsonData)
-	if err != nil {
-		return nil, err
-	}
-
-	hasDependents := make(map[string]bool)
-
-	for _, dependents := range adjacencyList {
-		for _, dependent := range dependents {
-			hasDependents[dependent] = true
-		}
-	}
-
-	result := []string{}
-	for node := range adjacencyList {
-		if !hasDependents[node] {
-			result = append(result, node)
-		}
-	}
-
-	sort.Strings(result)
-	return result, nil
+	return dggraph.Roots(graph), nil
 }
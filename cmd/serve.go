@@ -0,0 +1,336 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+)
+
+/*
+graphServer answers queries over HTTP using the dependency graph loaded once; the graph is
+reloaded when the dependency graph file is modified. Loaded graphs are never modified so that
+requests only need to hold the lock to get the current graph.
+*/
+type graphServer struct {
+	filePath string
+	readFile ReadFileFunc
+	limits   serverLimits
+
+	mutex    sync.RWMutex
+	graph    *dggraph.Graph
+	reversed *dggraph.Graph
+	modTime  time.Time
+}
+
+// serverLimits bound the searches of cycles so that a single request cannot run unbounded (0 disables a limit)
+type serverLimits struct {
+	timeout   time.Duration
+	maxCycles int
+}
+
+// cycleOptions applies the limits to the options of a request which may only lower them
+func (limits serverLimits) cycleOptions(options dggraph.CycleOptions) dggraph.CycleOptions {
+	if limits.timeout > 0 && (options.Timeout <= 0 || options.Timeout > limits.timeout) {
+		options.Timeout = limits.timeout
+	}
+	if limits.maxCycles > 0 && (options.MaxCycles <= 0 || options.MaxCycles > limits.maxCycles) {
+		options.MaxCycles = limits.maxCycles
+	}
+	return options
+}
+
+func newGraphServer(filePath string, limits serverLimits, readFile ReadFileFunc) (*graphServer, error) {
+	server := &graphServer{filePath: filePath, readFile: readFile, limits: limits}
+	if err := server.reload(); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// reload loads the dependency graph file replacing the current graph once it's loaded
+func (server *graphServer) reload() error {
+	var modTime time.Time
+	if server.filePath != StdinFilePath {
+		info, err := os.Stat(server.filePath)
+		if err != nil {
+			return err
+		}
+		modTime = info.ModTime()
+	}
+	graph, err := loadGraph(server.filePath, server.readFile)
+	if err != nil {
+		return err
+	}
+	reversed := graph.Reversed()
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.graph = graph
+	server.reversed = reversed
+	server.modTime = modTime
+	return nil
+}
+
+/*
+Reload the graph if the modification time of the dependency graph file has changed since it was loaded;
+the graph read from stdin is never reloaded. If the file cannot be loaded (e.g. while it's being written),
+the current graph is kept and the reload is attempted again on the next check.
+*/
+func (server *graphServer) reloadIfChanged() (bool, error) {
+	if server.filePath == StdinFilePath {
+		return false, nil
+	}
+	info, err := os.Stat(server.filePath)
+	if err != nil {
+		return false, err
+	}
+	server.mutex.RLock()
+	changed := !info.ModTime().Equal(server.modTime)
+	server.mutex.RUnlock()
+	if !changed {
+		return false, nil
+	}
+	if err := server.reload(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// watch checks whether the dependency graph file has changed with given interval until the server stops
+func (server *graphServer) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		reloaded, err := server.reloadIfChanged()
+		if err != nil {
+			log.Printf("reloading %s failed: %s\n", server.filePath, err)
+		} else if reloaded {
+			log.Printf("reloaded %s\n", server.filePath)
+		}
+	}
+}
+
+// graphs returns the current graph along with the reversed one
+func (server *graphServer) graphs() (*dggraph.Graph, *dggraph.Graph) {
+	server.mutex.RLock()
+	defer server.mutex.RUnlock()
+	return server.graph, server.reversed
+}
+
+/*
+queryParameters are the parameters of a request: the options of the query named after the flags
+of its command (see queryOptions) along with the targets and the output format.
+*/
+type queryParameters struct {
+	*optionReader
+	request *http.Request
+}
+
+func newQueryParameters(command string, request *http.Request) *queryParameters {
+	query := request.URL.Query()
+	return &queryParameters{
+		optionReader: &optionReader{
+			command:  command,
+			lookup:   func(option queryOption) []string { return query[option.name] },
+			describe: func(name string) string { return fmt.Sprintf("parameter %q", name) },
+		},
+		request: request,
+	}
+}
+
+// strings reads a parameter which is not an option of the query allowing both repeated parameters and comma-separated values
+func (parameters *queryParameters) strings(name string) []string {
+	values := []string{}
+	for _, value := range parameters.request.URL.Query()[name] {
+		for _, item := range strings.Split(value, ",") {
+			if item != "" {
+				values = append(values, item)
+			}
+		}
+	}
+	return values
+}
+
+// parameter reads a parameter which is not an option of the query
+func (parameters *queryParameters) parameter(name string) string {
+	return parameters.request.URL.Query().Get(name)
+}
+
+// content types of the output formats; JSON is the default output format of the server
+var outputContentTypes = map[string]string{
+	OutputJson:      "application/json",
+	OutputJsonLines: "application/x-ndjson",
+	OutputCsv:       "text/csv",
+}
+
+// header telling whether the search of a result was complete or stopped (e.g. by a timeout)
+const completeHeader = "X-Search-Complete"
+
+// searchResult is the result of a search that may have been stopped before it was complete (see CycleOptions)
+type searchResult struct {
+	result   any
+	complete bool
+}
+
+// handlerFunc answers a request using the current graph and its reversed graph
+type handlerFunc func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error)
+
+/*
+Wrap a handler to render its result in the format set with the `output` parameter (JSON by default)
+and to report errors as JSON objects with the error message; whether the search of a result was complete
+is reported in the X-Search-Complete header.
+*/
+func (server *graphServer) handle(command string, handler handlerFunc) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		parameters := newQueryParameters(command, request)
+		format := parameters.parameter("output")
+		if format == "" {
+			format = OutputJson
+		}
+		graph, reversed := server.graphs()
+		result, err := handler(parameters, graph, reversed)
+		if err == nil {
+			err = parameters.err
+		}
+		if search, isSearch := result.(searchResult); isSearch {
+			writer.Header().Set(completeHeader, strconv.FormatBool(search.complete))
+			result = search.result
+		}
+		var output []byte
+		if err == nil {
+			output, err = renderOutput(result, format, renderOptions{highlight: parameters.strings("highlight")})
+			if err != nil {
+				err = optionError{err.Error()}
+			}
+		}
+		if err != nil {
+			status := http.StatusUnprocessableEntity
+			if _, isOptionError := err.(optionError); isOptionError {
+				status = http.StatusBadRequest
+			}
+			writer.Header().Set("Content-Type", "application/json")
+			writer.WriteHeader(status)
+			json.NewEncoder(writer).Encode(map[string]string{"error": err.Error()})
+			return
+		}
+		contentType, exists := outputContentTypes[format]
+		if !exists {
+			contentType = "text/plain; charset=utf-8"
+		}
+		writer.Header().Set("Content-Type", contentType)
+		writer.Write(output)
+	}
+}
+
+// routes registers the endpoints of every query named after its command
+func (server *graphServer) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	route := func(command string, handler handlerFunc) {
+		mux.HandleFunc("GET /"+command, server.handle(command, handler))
+	}
+	route("dependencies", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		return dggraph.Dependencies(graph, parameters.strings("target"), parameters.dependencyOptions())
+	})
+	route("dependents", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		return dggraph.Dependencies(reversed, parameters.strings("target"), parameters.dependencyOptions())
+	})
+	route("paths", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		from, to, maxPaths := parameters.targets("from"), parameters.targets("to"), parameters.int("n")
+		if parameters.err != nil {
+			return nil, parameters.err
+		}
+		return dggraph.Paths(graph, from, to, maxPaths)
+	})
+	route("cycles", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		options := server.limits.cycleOptions(parameters.cycleOptions())
+		suggestBreaks, acyclic := parameters.bool("suggest-breaks"), parameters.acyclic()
+		if parameters.err != nil {
+			return nil, parameters.err
+		}
+		if acyclic {
+			return dggraph.RemoveEdges(graph, dggraph.FeedbackArcSet(graph)), nil
+		}
+		if suggestBreaks {
+			breaks, complete, err := dggraph.SuggestBreaks(graph, options)
+			return searchResult{CycleBreaks(breaks), complete}, err
+		}
+		result, complete, err := dggraph.Cycles(graph, options)
+		return searchResult{Cycles(result), complete}, err
+	})
+	route("components", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		if parameters.bool("strong") {
+			return getStrongComponents(graph, parameters.int("min-size")), nil
+		}
+		return dggraph.ConnectedComponents(graph), nil
+	})
+	route("condense", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		return dggraph.Condense(graph), nil
+	})
+	route("subgraph", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		root := parameters.targets("root")
+		if parameters.err != nil {
+			return nil, parameters.err
+		}
+		return dggraph.Subgraph(graph, root)
+	})
+	route("simplify", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		parameters.technique()
+		if parameters.err != nil {
+			return nil, parameters.err
+		}
+		return dggraph.TransitiveReduction(graph), nil
+	})
+	route("metrics", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		metricsItems, options := parameters.metrics()
+		if parameters.err != nil {
+			return nil, parameters.err
+		}
+		return getMetricsResult(graph, reversed, metricsItems, options), nil
+	})
+	route("roots", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		return dggraph.Roots(graph), nil
+	})
+	route("leaves", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		return dggraph.Leaves(graph), nil
+	})
+	route("toposort", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		if parameters.bool("layers") {
+			layers, err := dggraph.Layers(graph)
+			return Layers(layers), err
+		}
+		return dggraph.TopologicalSort(graph)
+	})
+	route("query", func(parameters *queryParameters, graph *dggraph.Graph, reversed *dggraph.Graph) (any, error) {
+		expression := parameters.parameter("expression")
+		if expression == "" {
+			return nil, optionError{`parameter "expression" is required`}
+		}
+		return dggraph.Query(graph, expression, nil)
+	})
+	return mux
+}
+
+/*
+Serve queries over HTTP on given address until the server fails; the dependency graph file
+is checked for changes with given interval (0 disables reloading) and searches of cycles are bounded by the limits.
+*/
+func serve(filePath string, address string, reloadInterval time.Duration, limits serverLimits, readFile ReadFileFunc) error {
+	server, err := newGraphServer(filePath, limits, readFile)
+	if err != nil {
+		return err
+	}
+	if reloadInterval > 0 {
+		go server.watch(reloadInterval)
+	}
+	log.Printf("serving %s on http://%s\n", filePath, address)
+	return http.ListenAndServe(address, server.routes())
+}
@@ -0,0 +1,168 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseServe struct {
+	path     string
+	status   int
+	expected string
+}
+
+func TestServe(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dg.json")
+	os.WriteFile(filePath, []byte(`
+	{
+		"foo.py": ["bar.py", "baz.py"],
+		"bar.py": ["baz.py"],
+		"spam.py": ["eggs.py"],
+		"eggs.py": ["spam.py"]
+	}
+	`), 0o644)
+	server, err := newGraphServer(filePath, serverLimits{}, DefaultReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.routes())
+	defer httpServer.Close()
+
+	cases := []testCaseServe{
+		{path: "/dependencies?target=foo.py", status: http.StatusOK, expected: `["bar.py","baz.py"]`},
+		{path: "/dependencies?target=foo.py,spam.py&transitive=true", status: http.StatusOK, expected: `["bar.py","baz.py","eggs.py","spam.py"]`},
+		{path: "/dependents?target=baz.py&target=eggs.py", status: http.StatusOK, expected: `["bar.py","foo.py","spam.py"]`},
+		{path: "/dependents?target=baz.py&depth=1&transitive=true&reflexive=true", status: http.StatusOK, expected: `["bar.py","baz.py","foo.py"]`},
+		{path: "/paths?from=foo.py&to=baz.py&n=1", status: http.StatusOK, expected: `[["foo.py","bar.py","baz.py"]]`},
+		{path: "/cycles", status: http.StatusOK, expected: `[["eggs.py","spam.py"]]`},
+		{path: "/cycles?through=foo.py&max-length=2&timeout=1s", status: http.StatusOK, expected: `[]`},
+		{path: "/cycles?suggest-breaks=true&output=csv", status: http.StatusOK, expected: "from,to,cycles\neggs.py,spam.py,1\n"},
+		{path: "/cycles?acyclic=true", status: http.StatusOK, expected: `{"bar.py":["baz.py"],"eggs.py":[],"foo.py":["bar.py","baz.py"],"spam.py":["eggs.py"]}`},
+		{path: "/components", status: http.StatusOK, expected: `[["bar.py","baz.py","foo.py"],["eggs.py","spam.py"]]`},
+		{path: "/components?strong=true&min-size=2", status: http.StatusOK, expected: `[{"size":2,"nodes":["eggs.py","spam.py"]}]`},
+		{path: "/condense", status: http.StatusOK, expected: `{"bar.py":["baz.py"],"eggs.py|spam.py":[],"foo.py":["bar.py","baz.py"]}`},
+		{path: "/subgraph?root=bar.py", status: http.StatusOK, expected: `{"bar.py":["baz.py"]}`},
+		{path: "/simplify?technique=transitive-reduction", status: http.StatusOK, expected: `{"bar.py":["baz.py"],"eggs.py":["spam.py"],"foo.py":["bar.py"],"spam.py":["eggs.py"]}`},
+		{path: "/metrics?metric=deps-direct&metric=rdeps-transitive", status: http.StatusOK, expected: `{"deps-direct":{"bar.py":1,"baz.py":0,"eggs.py":1,"foo.py":2,"spam.py":1},"rdeps-transitive":{"bar.py":1,"baz.py":2,"eggs.py":2,"foo.py":0,"spam.py":2}}`},
+		{path: "/metrics?sort-by=rdeps-transitive&top=2&output=csv", status: http.StatusOK, expected: "node,rdeps-transitive\nbaz.py,2\neggs.py,2\n"},
+		{path: "/roots", status: http.StatusOK, expected: `["foo.py"]`},
+		{path: "/leaves", status: http.StatusOK, expected: `["baz.py"]`},
+		{path: "/query?expression=" + "deps(foo.py)%20-%20baz.py", status: http.StatusOK, expected: `["bar.py","foo.py"]`},
+		// other output formats are rendered as by the commands
+		{path: "/roots?output=text", status: http.StatusOK, expected: "foo.py\n"},
+		// invalid requests
+		{path: "/paths?from=foo.py", status: http.StatusBadRequest, expected: `{"error":"parameter \"to\" is required"}`},
+		{path: "/dependencies?target=foo.py&depth=one", status: http.StatusBadRequest, expected: `{"error":"parameter \"depth\" must be an integer"}`},
+		{path: "/metrics?metric=size", status: http.StatusBadRequest, expected: `{"error":"invalid metric: size. Allowed metrics are: deps-direct,deps-transitive,rdeps-direct,rdeps-transitive,components-count,height,depth,critical-path,betweenness,pagerank,instability"}`},
+		{path: "/cycles?timeout=soon", status: http.StatusBadRequest, expected: `{"error":"parameter \"timeout\" must be a duration"}`},
+		{path: "/metrics?metric=components-count&table=true", status: http.StatusBadRequest, expected: `{"error":"metric components-count has a single value for the graph and cannot be joined in a table"}`},
+		{path: "/metrics?sort-by=height&min=low", status: http.StatusBadRequest, expected: `{"error":"parameter \"min\" must be a number"}`},
+		{path: "/cycles?acyclic=true&max-length=3", status: http.StatusBadRequest, expected: `{"error":"parameter \"acyclic\" cannot be combined with parameter \"max-length\""}`},
+		{path: "/simplify", status: http.StatusBadRequest, expected: `{"error":"invalid technique: . Allowed techniques are: transitive-reduction"}`},
+		{path: "/roots?output=yaml", status: http.StatusBadRequest, expected: `{"error":"invalid output: yaml. Allowed outputs are: text,json,jsonl,csv,dot,mermaid"}`},
+		{path: "/toposort?layers=true", status: http.StatusUnprocessableEntity, expected: `{"error":"dependency graph has cycles in strongly connected components: [eggs.py spam.py]"}`},
+		{path: "/dependencies?target=src/**", status: http.StatusUnprocessableEntity, expected: `{"error":"pattern \"src/**\" does not match any node"}`},
+	}
+	for _, testCase := range cases {
+		response, err := http.Get(httpServer.URL + testCase.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(response.Body)
+		response.Body.Close()
+		assert.Equal(t, testCase.status, response.StatusCode, testCase.path)
+		if testCase.status == http.StatusOK && strings.HasPrefix(testCase.path, "/cycles?acyclic") {
+			assert.Empty(t, response.Header.Get(completeHeader), testCase.path)
+		} else if testCase.status == http.StatusOK && strings.HasPrefix(testCase.path, "/cycles") {
+			assert.Equal(t, "true", response.Header.Get(completeHeader), testCase.path)
+		}
+		if json.Valid([]byte(testCase.expected)) {
+			assert.JSONEq(t, testCase.expected, string(body), testCase.path)
+		} else {
+			assert.Equal(t, testCase.expected, string(body), testCase.path)
+		}
+	}
+}
+
+func TestServeReload(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dg.json")
+	os.WriteFile(filePath, []byte(`{"foo.py": ["bar.py"]}`), 0o644)
+	server, err := newGraphServer(filePath, serverLimits{}, DefaultReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := server.reloadIfChanged()
+	assert.NoError(t, err)
+	assert.False(t, reloaded)
+
+	// the modification time is set explicitly as it may not change within the resolution of the file system
+	os.WriteFile(filePath, []byte(`{"foo.py": ["baz.py"]}`), 0o644)
+	modTime := time.Now().Add(time.Minute)
+	os.Chtimes(filePath, modTime, modTime)
+	reloaded, err = server.reloadIfChanged()
+	assert.NoError(t, err)
+	assert.True(t, reloaded)
+	graph, _ := server.graphs()
+	assert.Equal(t, []string{"baz.py", "foo.py"}, graph.Nodes())
+
+	// the current graph is kept if the file cannot be loaded
+	os.WriteFile(filePath, []byte(`{"foo.py": `), 0o644)
+	modTime = modTime.Add(time.Minute)
+	os.Chtimes(filePath, modTime, modTime)
+	_, err = server.reloadIfChanged()
+	assert.Error(t, err)
+	graph, _ = server.graphs()
+	assert.Equal(t, []string{"baz.py", "foo.py"}, graph.Nodes())
+}
+
+func TestServeLimits(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "dg.json")
+	os.WriteFile(filePath, []byte(`{"a.py": ["b.py"], "b.py": ["a.py"], "c.py": ["d.py"], "d.py": ["c.py"]}`), 0o644)
+	server, err := newGraphServer(filePath, serverLimits{timeout: time.Minute, maxCycles: 1}, DefaultReadFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server.routes())
+	defer httpServer.Close()
+
+	// requests cannot raise the limits of the server and are told the search was stopped
+	for _, path := range []string{"/cycles", "/cycles?max-cycles=10&timeout=1h"} {
+		response, err := http.Get(httpServer.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cycles [][]string
+		json.NewDecoder(response.Body).Decode(&cycles)
+		response.Body.Close()
+		assert.Len(t, cycles, 1, path)
+		assert.Equal(t, "false", response.Header.Get(completeHeader), path)
+	}
+
+	// other results are not searches
+	response, err := http.Get(httpServer.URL + "/roots")
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	assert.Empty(t, response.Header.Get(completeHeader))
+
+	// requests can lower the limits
+	limits := serverLimits{timeout: time.Minute, maxCycles: 100}
+	options := limits.cycleOptions(dggraph.CycleOptions{Timeout: time.Second})
+	assert.Equal(t, dggraph.CycleOptions{Timeout: time.Second, MaxCycles: 100}, options)
+	options = serverLimits{}.cycleOptions(dggraph.CycleOptions{MaxCycles: 5})
+	assert.Equal(t, dggraph.CycleOptions{MaxCycles: 5}, options)
+}
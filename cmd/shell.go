@@ -0,0 +1,604 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+	"github.com/peterh/liner"
+)
+
+// variable holding the nodes of the result of the last statement in the shell
+const shellLastVariable = "last"
+
+// maximum number of node names offered for completion
+const shellMaxCompletions = 1000
+
+// errShellExit is returned when the shell is to be exited
+var errShellExit = errors.New("exit")
+
+/*
+shell evaluates statements (the commands of the tool) against the dependency graph loaded once;
+nodes of the result of every statement are kept in variables that can be used in next statements.
+*/
+type shell struct {
+	filePath string
+	readFile ReadFileFunc
+	output   io.Writer
+
+	graph    *dggraph.Graph
+	reversed *dggraph.Graph
+	// nodes of the results of previous statements
+	variables map[string][]string
+}
+
+// shellFlags are the flags of a statement with all values of repeated flags
+type shellFlags map[string][]string
+
+// options reads the options of the query of a command from the flags of a statement
+func (flags shellFlags) options(command string) *optionReader {
+	return &optionReader{
+		command:  command,
+		lookup:   func(option queryOption) []string { return flags[option.name] },
+		describe: func(name string) string { return "flag --" + name },
+	}
+}
+
+// output gets the output format of a statement (`--output` is accepted by all statements)
+func (flags shellFlags) output() string {
+	values := flags["output"]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+type shellStatement struct {
+	// arguments of the statement in its usage; its flags are the options of the query of the command (see queryOptions)
+	arguments string
+	// default output format which is plain text for lists of nodes as for the commands
+	output string
+	// the arguments are not split and variables are not expanded in them (e.g. for query expressions)
+	rawArguments bool
+	run          func(shell *shell, args []string, options *optionReader) (any, error)
+}
+
+var shellStatements = map[string]shellStatement{
+	"dependencies": {
+		arguments: "<targets>", output: OutputText,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			dependencyOptions := options.dependencyOptions()
+			if options.err != nil {
+				return nil, options.err
+			}
+			return dggraph.Dependencies(shell.graph, args, dependencyOptions)
+		},
+	},
+	"dependents": {
+		arguments: "<targets>", output: OutputText,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			dependencyOptions := options.dependencyOptions()
+			if options.err != nil {
+				return nil, options.err
+			}
+			return dggraph.Dependencies(shell.reversed, args, dependencyOptions)
+		},
+	},
+	"paths": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			from, to, maxPaths := options.targets("from"), options.targets("to"), options.int("n")
+			if options.err != nil {
+				return nil, options.err
+			}
+			return dggraph.Paths(shell.graph, from, to, maxPaths)
+		},
+	},
+	"cycles": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			cycleOptions := options.cycleOptions()
+			suggestBreaks, acyclic := options.bool("suggest-breaks"), options.acyclic()
+			if options.err != nil {
+				return nil, options.err
+			}
+			if acyclic {
+				return dggraph.RemoveEdges(shell.graph, dggraph.FeedbackArcSet(shell.graph)), nil
+			}
+			var result any
+			var err error
+			complete := true
+			if suggestBreaks {
+				var breaks []dggraph.CycleBreak
+				breaks, complete, err = dggraph.SuggestBreaks(shell.graph, cycleOptions)
+				result = CycleBreaks(breaks)
+			} else {
+				var cycles [][]string
+				cycles, complete, err = dggraph.Cycles(shell.graph, cycleOptions)
+				result = Cycles(cycles)
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !complete {
+				fmt.Fprintln(shell.output, incompleteCyclesWarning)
+			}
+			return result, nil
+		},
+	},
+	"components": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			strong, minSize := options.bool("strong"), options.int("min-size")
+			if options.err != nil {
+				return nil, options.err
+			}
+			if !strong {
+				return dggraph.ConnectedComponents(shell.graph), nil
+			}
+			return getStrongComponents(shell.graph, minSize), nil
+		},
+	},
+	"condense": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			return dggraph.Condense(shell.graph), nil
+		},
+	},
+	"subgraph": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			root := options.targets("root")
+			if options.err != nil {
+				return nil, options.err
+			}
+			return dggraph.Subgraph(shell.graph, root)
+		},
+	},
+	"simplify": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			options.technique()
+			if options.err != nil {
+				return nil, options.err
+			}
+			return dggraph.TransitiveReduction(shell.graph), nil
+		},
+	},
+	"metrics": {
+		output: OutputJson,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			metricsItems, metricsOptions := options.metrics()
+			if options.err != nil {
+				return nil, options.err
+			}
+			return getMetricsResult(shell.graph, shell.reversed, metricsItems, metricsOptions), nil
+		},
+	},
+	"roots": {
+		output: OutputText,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			return dggraph.Roots(shell.graph), nil
+		},
+	},
+	"leaves": {
+		output: OutputText,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			return dggraph.Leaves(shell.graph), nil
+		},
+	},
+	"toposort": {
+		output: OutputText,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			layers := options.bool("layers")
+			if options.err != nil {
+				return nil, options.err
+			}
+			if layers {
+				result, err := dggraph.Layers(shell.graph)
+				return Layers(result), err
+			}
+			return dggraph.TopologicalSort(shell.graph)
+		},
+	},
+	"query": {
+		arguments: "<expression> (shell variables such as $last can be used in the expression)",
+		output:    OutputText, rawArguments: true,
+		run: func(shell *shell, args []string, options *optionReader) (any, error) {
+			return dggraph.Query(shell.graph, strings.Join(args, " "), shell.variables)
+		},
+	},
+}
+
+// shellUsage describes a statement with its arguments and flags
+func shellUsage(name string) string {
+	usage := []string{name}
+	for _, part := range []string{shellStatements[name].arguments, optionsUsage(name)} {
+		if part != "" {
+			usage = append(usage, part)
+		}
+	}
+	return strings.Join(usage, " ")
+}
+
+var shellAliases = map[string]string{
+	"deps":  "dependencies",
+	"rdeps": "dependents",
+}
+
+var shellDirectives = []string{":help", ":quit", ":reload", ":vars"}
+
+func newShell(filePath string, readFile ReadFileFunc, output io.Writer) (*shell, error) {
+	shell := &shell{filePath: filePath, readFile: readFile, output: output, variables: make(map[string][]string)}
+	if err := shell.reload(); err != nil {
+		return nil, err
+	}
+	return shell, nil
+}
+
+func (shell *shell) reload() error {
+	graph, err := loadGraph(shell.filePath, shell.readFile)
+	if err != nil {
+		return err
+	}
+	shell.graph = graph
+	shell.reversed = graph.Reversed()
+	return nil
+}
+
+// splitShellWords splits a line into words on whitespace except within single or double quotes
+func splitShellWords(line string) ([]string, error) {
+	words := []string{}
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, c := range line {
+		switch {
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(c)
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quoted word")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// assignments store the nodes of the result in a variable in addition to $last, e.g. `app = deps --transitive app.py`
+var shellAssignment = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+/*
+Execute a line of the shell: a directive such as `:reload`, or a statement (optionally assigned
+to a variable) whose result is written to the output. Words starting with `$` are replaced
+with the nodes stored in the variable of that name.
+*/
+func (shell *shell) execute(line string) error {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+	if strings.HasPrefix(line, ":") {
+		return shell.executeDirective(line)
+	}
+
+	variable := ""
+	if match := shellAssignment.FindStringSubmatch(line); match != nil {
+		variable, line = match[1], match[2]
+	}
+	name, rest, _ := strings.Cut(line, " ")
+	if alias, exists := shellAliases[name]; exists {
+		name = alias
+	}
+	statement, exists := shellStatements[name]
+	if !exists {
+		return fmt.Errorf("unknown statement: %s (see :help)", name)
+	}
+
+	var words []string
+	if statement.rawArguments {
+		// only the flags preceding the arguments are recognized
+		rest = strings.TrimSpace(rest)
+		for strings.HasPrefix(rest, "--") {
+			flag, remaining, _ := strings.Cut(rest, " ")
+			words = append(words, flag)
+			rest = strings.TrimSpace(remaining)
+		}
+	} else {
+		split, err := splitShellWords(rest)
+		if err != nil {
+			return err
+		}
+		words = split
+	}
+	args, flags, err := shell.parseWords(name, words)
+	if err != nil {
+		return err
+	}
+	if statement.rawArguments && rest != "" {
+		args = []string{rest}
+	}
+
+	result, err := statement.run(shell, args, flags.options(name))
+	if err != nil {
+		return err
+	}
+	if nodes := resultNodes(result); nodes != nil {
+		shell.variables[shellLastVariable] = nodes
+		if variable != "" {
+			shell.variables[variable] = nodes
+		}
+	}
+
+	format := flags.output()
+	if format == "" {
+		format = outputFormat
+	}
+	if format == "" {
+		format = statement.output
+	}
+	targets := slices.Concat(args, flags["from"], flags["to"], flags["root"])
+	output, err := renderOutput(result, format, newRenderOptions(targets))
+	if err != nil {
+		return err
+	}
+	shell.output.Write(output)
+	return nil
+}
+
+// parseWords separates flags from arguments of a statement expanding variables in both
+func (shell *shell) parseWords(name string, words []string) ([]string, shellFlags, error) {
+	args := []string{}
+	flags := make(shellFlags)
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "--") {
+			expanded, err := shell.expand(word)
+			if err != nil {
+				return nil, nil, err
+			}
+			args = append(args, expanded...)
+			continue
+		}
+		flag, value, hasValue := strings.Cut(strings.TrimPrefix(word, "--"), "=")
+		option, exists := lookupOption(name, flag)
+		switch {
+		case !exists && flag != "output":
+			return nil, nil, fmt.Errorf("unknown flag: --%s (usage: %s)", flag, shellUsage(name))
+		case option.kind == boolOption && flag != "output":
+			if !hasValue {
+				value = "true"
+			}
+			flags[flag] = append(flags[flag], value)
+		default:
+			if !hasValue {
+				if i+1 == len(words) {
+					return nil, nil, fmt.Errorf("flag --%s requires a value", flag)
+				}
+				i++
+				value = words[i]
+			}
+			expanded, err := shell.expand(value)
+			if err != nil {
+				return nil, nil, err
+			}
+			flags[flag] = append(flags[flag], expanded...)
+		}
+	}
+	return args, flags, nil
+}
+
+// expand replaces a variable with the nodes it holds; other words are kept as is
+func (shell *shell) expand(word string) ([]string, error) {
+	if !strings.HasPrefix(word, "$") {
+		return []string{word}, nil
+	}
+	nodes, exists := shell.variables[strings.TrimPrefix(word, "$")]
+	if !exists {
+		return nil, fmt.Errorf("undefined variable: %s", word)
+	}
+	return nodes, nil
+}
+
+// resultNodes gets the nodes of a result to be stored in a variable; nil if the result has no nodes (e.g. metrics)
+func resultNodes(result any) []string {
+	switch value := result.(type) {
+	case []string:
+		return value
+	case [][]string, Cycles, CycleBreaks, AdjacencyList:
+		graph, _ := resultGraph(value)
+		return graph.Nodes()
+	case StrongComponents:
+		nodes := []string{}
+		for _, component := range value {
+			nodes = append(nodes, component.Nodes...)
+		}
+		slices.Sort(nodes)
+		return nodes
+	case Layers:
+		nodes := slices.Concat(value...)
+		slices.Sort(nodes)
+		return nodes
+	}
+	return nil
+}
+
+func (shell *shell) executeDirective(line string) error {
+	switch line {
+	case ":quit", ":exit", ":q":
+		return errShellExit
+	case ":reload":
+		if err := shell.reload(); err != nil {
+			return err
+		}
+		fmt.Fprintf(shell.output, "reloaded %s: %d nodes\n", shell.filePath, shell.graph.Size())
+	case ":vars":
+		for _, name := range sortedKeys(shell.variables) {
+			fmt.Fprintf(shell.output, "$%s: %d nodes\n", name, len(shell.variables[name]))
+		}
+	case ":help":
+		fmt.Fprintln(shell.output, "Statements (the result can be assigned to a variable with `name = statement`):")
+		for _, name := range sortedKeys(shellStatements) {
+			fmt.Fprintf(shell.output, "  %s\n", shellUsage(name))
+		}
+		fmt.Fprintln(shell.output, "Aliases: deps (dependencies), rdeps (dependents)")
+		fmt.Fprintln(shell.output, "Flags of all statements: --output=<format>")
+		fmt.Fprintln(shell.output, "Variables: $last holds the nodes of the last result")
+		fmt.Fprintln(shell.output, "Directives: :reload (load the dependency graph file again), :vars, :help, :quit")
+	default:
+		return fmt.Errorf("unknown directive: %s (see :help)", line)
+	}
+	return nil
+}
+
+/*
+Complete the word at the cursor: statements and directives for the first word, flags of the statement,
+variables, and node names otherwise (including values of flags such as `--from=`).
+*/
+func (shell *shell) complete(line string, pos int) (string, []string, string) {
+	start := strings.LastIndexAny(line[:pos], " (,") + 1
+	head, word, tail := line[:start], line[start:pos], line[pos:]
+
+	candidates := []string{}
+	if strings.TrimSpace(head) == "" {
+		for name := range shellStatements {
+			candidates = append(candidates, name+" ")
+		}
+		for alias := range shellAliases {
+			candidates = append(candidates, alias+" ")
+		}
+		candidates = append(candidates, shellDirectives...)
+		return head, completions(candidates, word), tail
+	}
+
+	name, _, _ := strings.Cut(strings.TrimSpace(head), " ")
+	if alias, exists := shellAliases[name]; exists {
+		name = alias
+	}
+	switch {
+	case strings.HasPrefix(word, "--") && !strings.Contains(word, "="):
+		candidates = append(candidates, "--output=")
+		for _, option := range queryOptions[name] {
+			if option.kind == boolOption {
+				candidates = append(candidates, "--"+option.name+" ")
+			} else {
+				candidates = append(candidates, "--"+option.name+"=")
+			}
+		}
+		return head, completions(candidates, word), tail
+	case strings.HasPrefix(word, "--"):
+		// completing the value of a flag
+		flag, value, _ := strings.Cut(word, "=")
+		head += flag + "="
+		word = value
+	}
+
+	if strings.HasPrefix(word, "$") {
+		for variable := range shell.variables {
+			candidates = append(candidates, "$"+variable)
+		}
+		return head, completions(candidates, word), tail
+	}
+	// node names are sorted so the ones with the prefix follow each other
+	nodes := shell.graph.Nodes()
+	for i := sort.SearchStrings(nodes, word); i < len(nodes) && strings.HasPrefix(nodes[i], word); i++ {
+		if len(candidates) == shellMaxCompletions {
+			break
+		}
+		candidates = append(candidates, nodes[i])
+	}
+	return head, candidates, tail
+}
+
+// completions filters the candidates by the prefix sorting them
+func completions(candidates []string, prefix string) []string {
+	result := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			result = append(result, candidate)
+		}
+	}
+	slices.Sort(result)
+	return result
+}
+
+/*
+Run the interactive shell reading statements until the end of the input or until `:quit`;
+the history is kept in the `.dg_query_history` file in the home directory.
+*/
+func runShell(filePath string, readFile ReadFileFunc) error {
+	if filePath == StdinFilePath {
+		return fmt.Errorf("the dependency graph cannot be read from stdin in the shell")
+	}
+	shell, err := newShell(filePath, readFile, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	prompt := liner.NewLiner()
+	defer prompt.Close()
+	prompt.SetCtrlCAborts(true)
+	prompt.SetTabCompletionStyle(liner.TabPrints)
+	prompt.SetWordCompleter(shell.complete)
+
+	historyPath := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		historyPath = filepath.Join(home, ".dg_query_history")
+		if history, err := os.Open(historyPath); err == nil {
+			prompt.ReadHistory(history)
+			history.Close()
+		}
+	}
+
+	for {
+		line, err := prompt.Prompt("dg> ")
+		if err == io.EOF || err == liner.ErrPromptAborted {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) != "" {
+			prompt.AppendHistory(line)
+		}
+		if err := shell.execute(line); err == errShellExit {
+			break
+		} else if err != nil {
+			fmt.Fprintln(shell.output, err)
+		}
+	}
+
+	if historyPath != "" {
+		if history, err := os.Create(historyPath); err == nil {
+			prompt.WriteHistory(history)
+			history.Close()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseShell struct {
+	line     string
+	expected string
+}
+
+func newMockShell(t *testing.T, output *bytes.Buffer) *shell {
+	input := []byte(`
+	{
+		"src/app/main.py": ["src/lib/db.py", "src/lib/log.py"],
+		"src/app/cli.py": ["src/lib/log.py"],
+		"src/lib/db.py": ["src/lib/log.py"]
+	}
+	`)
+	MockReadFile := func(filePath string) ([]byte, error) {
+		return input, nil
+	}
+	shell, err := newShell("mock.json", MockReadFile, output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return shell
+}
+
+func TestShellExecute(t *testing.T) {
+	var output bytes.Buffer
+	shell := newMockShell(t, &output)
+
+	// statements are executed in order so that variables can be used in next statements
+	cases := []testCaseShell{
+		{line: "deps src/app/main.py", expected: "src/lib/db.py\nsrc/lib/log.py\n"},
+		{line: "rdeps $last --reflexive", expected: "src/app/cli.py\nsrc/app/main.py\nsrc/lib/db.py\nsrc/lib/log.py\n"},
+		{line: "app = dependencies --transitive 'src/app/*'", expected: "src/lib/db.py\nsrc/lib/log.py\n"},
+		{line: "roots", expected: "src/app/cli.py\nsrc/app/main.py\n"},
+		{line: "dependents $app --depth 1 --transitive --output=json", expected: "[\n  \"src/app/cli.py\",\n  \"src/app/main.py\",\n  \"src/lib/db.py\"\n]\n"},
+		{line: "paths --from=src/app/main.py --to=src/lib/log.py --output=text", expected: "src/app/main.py src/lib/db.py src/lib/log.py\nsrc/app/main.py src/lib/log.py\n"},
+		{line: "leaves", expected: "src/lib/log.py\n"},
+		{line: "query deps(src/app/cli.py) + $app", expected: "src/app/cli.py\nsrc/lib/db.py\nsrc/lib/log.py\n"},
+		{line: "query --output=csv roots($app)", expected: "node\nsrc/lib/db.py\n"},
+		{line: "subgraph --root=src/lib/db.py --output=text", expected: "src/lib/db.py -> src/lib/log.py\n"},
+		{line: "metrics --metric=deps-direct --output=csv", expected: "metric,node,value\ndeps-direct,src/app/cli.py,1\ndeps-direct,src/app/main.py,2\ndeps-direct,src/lib/db.py,1\ndeps-direct,src/lib/log.py,0\n"},
+		{line: "components --strong --output=csv", expected: "size,nodes\n1,src/app/cli.py\n1,src/app/main.py\n1,src/lib/db.py\n1,src/lib/log.py\n"},
+		{line: "toposort --layers", expected: "0 src/lib/log.py\n1 src/app/cli.py src/lib/db.py\n2 src/app/main.py\n"},
+		{line: ":vars", expected: "$app: 2 nodes\n$last: 4 nodes\n"},
+		{line: "cycles --max-length=2 --timeout=1s", expected: "[]\n"},
+		{line: ":reload", expected: "reloaded mock.json: 4 nodes\n"},
+	}
+	for _, testCase := range cases {
+		err := shell.execute(testCase.line)
+		if err != nil {
+			t.Fatal(testCase.line, err)
+		}
+		assert.Equal(t, testCase.expected, output.String(), testCase.line)
+		output.Reset()
+	}
+}
+
+func TestShellExecuteInvalid(t *testing.T) {
+	var output bytes.Buffer
+	shell := newMockShell(t, &output)
+
+	for _, line := range []string{
+		"dependencies $missing",
+		"dependencies --transitive=maybe foo.py",
+		"dependencies --depth",
+		"dependencies --from=foo.py",
+		"paths --from=src/app/main.py",
+		"metrics --metric=size",
+		"cycles --timeout=soon",
+		"build //...",
+		"deps 'src/app",
+		":restart",
+	} {
+		assert.Error(t, shell.execute(line), line)
+	}
+	assert.Equal(t, errShellExit, shell.execute(":quit"))
+	assert.Empty(t, output.String())
+}
+
+func TestShellComplete(t *testing.T) {
+	var output bytes.Buffer
+	shell := newMockShell(t, &output)
+	shell.variables["app"] = []string{}
+
+	type testCaseComplete struct {
+		line     string
+		head     string
+		expected []string
+	}
+	cases := []testCaseComplete{
+		{line: "de", head: "", expected: []string{"dependencies ", "dependents ", "deps "}},
+		{line: ":r", head: "", expected: []string{":reload"}},
+		{line: "deps src/app/", head: "deps ", expected: []string{"src/app/cli.py", "src/app/main.py"}},
+		{line: "deps --t", head: "deps ", expected: []string{"--transitive "}},
+		{line: "paths --from=src/lib/d", head: "paths --from=", expected: []string{"src/lib/db.py"}},
+		{line: "rdeps $a", head: "rdeps ", expected: []string{"$app"}},
+		{line: "query deps(src/app/m", head: "query deps(", expected: []string{"src/app/main.py"}},
+		{line: "deps spam", head: "deps ", expected: []string{}},
+	}
+	for _, testCase := range cases {
+		head, completions, tail := shell.complete(testCase.line, len(testCase.line))
+		assert.Equal(t, testCase.head, head, testCase.line)
+		assert.Equal(t, testCase.expected, completions, testCase.line)
+		assert.Equal(t, "", tail, testCase.line)
+	}
+}
@@ -105,7 +105,7 @@ func TestSimplifyTransitiveReduction(t *testing.T) {
 			t.Fail()
 		}
 
-		adjacencyListExpected, err := loadJsonFile([]byte(testCase.expected))
+		adjacencyListExpected, err := loadAdjacencyList("expected.json", []byte(testCase.expected))
 		if err != nil {
 			t.Fail()
 		}
@@ -128,7 +128,7 @@ func TestExtractSubgraph(t *testing.T) {
 			t.Fail()
 		}
 
-		adjacencyListExpected, err := loadJsonFile([]byte(testCase.expected))
+		adjacencyListExpected, err := loadAdjacencyList("expected.json", []byte(testCase.expected))
 		if err != nil {
 			t.Fail()
 		}
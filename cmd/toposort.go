@@ -0,0 +1,31 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import "github.com/AlexTereshenkov/dg-query/pkg/dggraph"
+
+// Layers are groups of nodes that can be built in parallel, the leaves first
+type Layers [][]string
+
+// to be used in non-unit tests
+var Toposort = toposort
+
+// toposort orders nodes so that every node comes after its dependencies
+func toposort(filePath string, readFile ReadFileFunc) ([]string, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, err
+	}
+	return dggraph.TopologicalSort(graph)
+}
+
+// buildLayers groups nodes into layers where every node is in the layer right after its deepest dependency
+func buildLayers(filePath string, readFile ReadFileFunc) (Layers, error) {
+	graph, err := loadGraph(filePath, readFile)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := dggraph.Layers(graph)
+	return Layers(layers), err
+}
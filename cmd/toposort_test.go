@@ -0,0 +1,73 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCaseToposort struct {
+	input          []byte
+	expectedOrder  []string
+	expectedLayers Layers
+	expectedError  string
+}
+
+func TestToposort(t *testing.T) {
+	cases := []testCaseToposort{
+		// empty graph
+		{
+			input:          []byte(`{}`),
+			expectedOrder:  []string{},
+			expectedLayers: Layers{},
+		},
+		// dependencies not listed in the adjacency list are leaves
+		{
+			input: []byte(`{
+				"foo.py": ["bar.py", "baz.py"],
+				"bar.py": ["baz.py"],
+				"spam.py": ["baz.py"]
+			}`),
+			expectedOrder:  []string{"baz.py", "bar.py", "spam.py", "foo.py"},
+			expectedLayers: Layers{{"baz.py"}, {"bar.py", "spam.py"}, {"foo.py"}},
+		},
+		// cycles are reported
+		{
+			input: []byte(`{
+				"foo.py": ["bar.py"],
+				"bar.py": ["foo.py", "baz.py"]
+			}`),
+			expectedError: "dependency graph has cycles in strongly connected components: [bar.py foo.py]",
+		},
+	}
+	for _, testCase := range cases {
+		MockReadFile := func(filePath string) ([]byte, error) {
+			return testCase.input, nil
+		}
+		order, err := toposort("mock.json", MockReadFile)
+		layers, layersErr := buildLayers("mock.json", MockReadFile)
+		if testCase.expectedError != "" {
+			assert.EqualError(t, err, testCase.expectedError)
+			assert.EqualError(t, layersErr, testCase.expectedError)
+			continue
+		}
+		assert.NoError(t, err)
+		assert.NoError(t, layersErr)
+		assert.Equal(t, testCase.expectedOrder, order)
+		assert.Equal(t, testCase.expectedLayers, layers)
+	}
+}
+
+func TestRenderLayers(t *testing.T) {
+	layers := Layers{{"baz.py"}, {"bar.py", "spam.py"}}
+	output, err := renderOutput(layers, OutputCsv, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "layer,nodes\n0,baz.py\n1,bar.py spam.py\n", string(output))
+
+	output, err = renderOutput(layers, OutputJsonLines, renderOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"layer\":0,\"nodes\":[\"baz.py\"]}\n{\"layer\":1,\"nodes\":[\"bar.py\",\"spam.py\"]}\n", string(output))
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"slices"
+	"strings"
+)
+
+// AffectedNode is a node affected by changes along with the reason it's affected
+type AffectedNode struct {
+	Node string `json:"node"`
+	// chain of dependents from a changed node to the affected node
+	Reason []string `json:"reason"`
+}
+
+/*
+Affected gets the nodes affected by changes of given nodes, i.e. the changed nodes and their transitive dependents,
+sorted; every node comes with the shortest chain of dependents leading to it from a changed node. The graph must be
+the reverse dependency graph (see Graph.Reversed). The changed nodes that are not part of the graph are ignored.
+*/
+func Affected(reversed *Graph, changed []string) []AffectedNode {
+	search := reversed.NewTraversal()
+	ids := reversed.ToIds(changed)
+	affected := append(search.Reachable(ids, 0, nil), ids...)
+	slices.Sort(affected)
+	affected = slices.Compact(affected)
+
+	result := make([]AffectedNode, len(affected))
+	for i, id := range affected {
+		result[i] = AffectedNode{Node: reversed.Name(id), Reason: reversed.ToNames(search.Chain(id))}
+	}
+	return result
+}
+
+/*
+Owners maps paths of files to the nodes owning them by path prefix (e.g. a directory of a build target);
+a prefix is matched as is, so the prefix of a directory should end with a slash.
+*/
+type Owners map[string][]string
+
+/*
+FileNodes maps a file to the nodes of the graph: the node named after the file if there is one
+and the nodes of the longest prefix of the file otherwise; owners that are not nodes of the graph are skipped,
+so none are returned if the file has no owners or none of its owners are in the graph.
+*/
+func (owners Owners) FileNodes(graph *Graph, file string) []string {
+	if _, exists := graph.Lookup(file); exists {
+		return []string{file}
+	}
+	longest := ""
+	found := false
+	for prefix := range owners {
+		if strings.HasPrefix(file, prefix) && (!found || len(prefix) > len(longest)) {
+			longest, found = prefix, true
+		}
+	}
+	nodes := []string{}
+	if !found {
+		return nodes
+	}
+	for _, node := range owners[longest] {
+		if _, exists := graph.Lookup(node); exists {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes
+}
@@ -0,0 +1,54 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffected(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"app/cli.py":  {"lib/log.py"},
+		"app/main.py": {"lib/db.py"},
+		"lib/db.py":   {"lib/log.py"},
+		"lib/util.py": {},
+	})
+	affected := Affected(graph.Reversed(), []string{"lib/log.py", "unknown.py"})
+	assert.Equal(t, []AffectedNode{
+		{Node: "app/cli.py", Reason: []string{"lib/log.py", "app/cli.py"}},
+		{Node: "app/main.py", Reason: []string{"lib/log.py", "lib/db.py", "app/main.py"}},
+		{Node: "lib/db.py", Reason: []string{"lib/log.py", "lib/db.py"}},
+		{Node: "lib/log.py", Reason: []string{"lib/log.py"}},
+	}, affected)
+
+	// the shortest chain from any changed node is the reason
+	affected = Affected(graph.Reversed(), []string{"lib/log.py", "lib/db.py"})
+	assert.Equal(t, []string{"lib/db.py", "app/main.py"}, affected[1].Reason)
+
+	assert.Equal(t, []AffectedNode{}, Affected(graph.Reversed(), []string{}))
+}
+
+func TestOwnersFileNodes(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"//app:main":     {"//lib:db"},
+		"//lib:db":       {},
+		"lib/db/util.py": {},
+	})
+	owners := Owners{
+		"app/":    {"//app:main"},
+		"lib/":    {"//lib:db"},
+		"lib/db/": {"//lib:db", "//lib:db_test"},
+		"docs/":   {"//docs:site"},
+	}
+	// nodes named after files take precedence
+	assert.Equal(t, []string{"lib/db/util.py"}, owners.FileNodes(graph, "lib/db/util.py"))
+	// the longest prefix wins and owners not in the graph are skipped
+	assert.Equal(t, []string{"//lib:db"}, owners.FileNodes(graph, "lib/db/models.py"))
+	assert.Equal(t, []string{"//lib:db"}, owners.FileNodes(graph, "lib/log.py"))
+	assert.Equal(t, []string{}, owners.FileNodes(graph, "README.md"))
+	// files whose owners are all unknown are not mapped
+	assert.Equal(t, []string{}, owners.FileNodes(graph, "docs/index.md"))
+}
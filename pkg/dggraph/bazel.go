@@ -0,0 +1,312 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// kind of a Bazel target, i.e. a rule class such as `go_library` or a file kind
+const AttributeKind = "kind"
+
+const (
+	bazelKindSourceFile    = "source file"
+	bazelKindGeneratedFile = "generated file"
+)
+
+// bazelTarget is a subset of fields of the `Target` message of Bazel's `build.proto`
+type bazelTarget struct {
+	Rule *struct {
+		Name      string   `json:"name"`
+		RuleClass string   `json:"ruleClass"`
+		RuleInput []string `json:"ruleInput"`
+	} `json:"rule"`
+	SourceFile *struct {
+		Name string `json:"name"`
+	} `json:"sourceFile"`
+	GeneratedFile *struct {
+		Name           string `json:"name"`
+		GeneratingRule string `json:"generatingRule"`
+	} `json:"generatedFile"`
+}
+
+// bazelGraphBuilder collects targets of Bazel query output along with their kinds
+type bazelGraphBuilder struct {
+	adjacencyList AdjacencyList
+	attributes    NodeAttributes
+}
+
+func newBazelGraphBuilder() *bazelGraphBuilder {
+	return &bazelGraphBuilder{adjacencyList: make(AdjacencyList), attributes: make(NodeAttributes)}
+}
+
+func (builder *bazelGraphBuilder) addRule(name string, ruleClass string, ruleInputs []string) {
+	builder.addTarget(name, ruleClass, ruleInputs...)
+}
+
+func (builder *bazelGraphBuilder) addSourceFile(name string) {
+	builder.addTarget(name, bazelKindSourceFile)
+}
+
+// generated files depend on the rule generating them, the same way as in the `graph` output
+func (builder *bazelGraphBuilder) addGeneratedFile(name string, generatingRule string) {
+	builder.addTarget(name, bazelKindGeneratedFile, generatingRule)
+}
+
+func (builder *bazelGraphBuilder) addTarget(name string, kind string, dependencies ...string) {
+	if name == "" {
+		return
+	}
+	builder.adjacencyList.AddNode(name)
+	for _, dep := range dependencies {
+		builder.adjacencyList.appendEdge(name, dep)
+	}
+	if kind != "" {
+		builder.attributes[name] = map[string]any{AttributeKind: kind}
+	}
+}
+
+// build completes the adjacency list dropping the duplicate edges
+func (builder *bazelGraphBuilder) build() (AdjacencyList, NodeAttributes, error) {
+	builder.adjacencyList.dropDuplicateEdges()
+	return builder.adjacencyList, builder.attributes, nil
+}
+
+/*
+Load output of `bazel query --output=graph`. Bazel groups nodes having the same dependencies and dependents
+into a single node (unless `--nograph:factored` is passed) with the labels separated by a literal "\n",
+e.g. "//a:b\n//a:c", so every grouped node is expanded into individual targets.
+*/
+func loadBazelGraphFile(data []byte) (AdjacencyList, error) {
+	factored, err := loadDotFile(data)
+	if err != nil {
+		return nil, err
+	}
+	adjacencyList := make(AdjacencyList)
+	for group, dependencyGroups := range factored {
+		nodes := strings.Split(group, `\n`)
+		for _, node := range nodes {
+			adjacencyList.AddNode(node)
+		}
+		for _, dependencyGroup := range dependencyGroups {
+			for _, dependency := range strings.Split(dependencyGroup, `\n`) {
+				for _, node := range nodes {
+					adjacencyList.appendEdge(node, dependency)
+				}
+			}
+		}
+	}
+	adjacencyList.dropDuplicateEdges()
+	return adjacencyList, nil
+}
+
+// loadBazelJsonProtoFile loads output of `bazel query --output=streamed_jsonproto` with one target per line
+func loadBazelJsonProtoFile(data []byte) (AdjacencyList, NodeAttributes, error) {
+	builder := newBazelGraphBuilder()
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var target bazelTarget
+		if err := json.Unmarshal(line, &target); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		switch {
+		case target.Rule != nil:
+			builder.addRule(target.Rule.Name, target.Rule.RuleClass, target.Rule.RuleInput)
+		case target.SourceFile != nil:
+			builder.addSourceFile(target.SourceFile.Name)
+		case target.GeneratedFile != nil:
+			builder.addGeneratedFile(target.GeneratedFile.Name, target.GeneratedFile.GeneratingRule)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, err
+	}
+	return builder.build()
+}
+
+type bazelXmlQuery struct {
+	Rules []struct {
+		Name       string `xml:"name,attr"`
+		Class      string `xml:"class,attr"`
+		RuleInputs []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"rule-input"`
+	} `xml:"rule"`
+	SourceFiles []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"source-file"`
+	GeneratedFiles []struct {
+		Name           string `xml:"name,attr"`
+		GeneratingRule string `xml:"generating-rule,attr"`
+	} `xml:"generated-file"`
+}
+
+// Bazel declares XML 1.1 which is not supported by the standard library decoder
+var xmlVersionDeclaration = regexp.MustCompile(`^(\s*<\?xml[^>]*version=["'])1\.1(["'])`)
+
+// loadBazelXmlFile loads output of `bazel query --output=xml`
+func loadBazelXmlFile(data []byte) (AdjacencyList, NodeAttributes, error) {
+	var query bazelXmlQuery
+	if err := xml.Unmarshal(xmlVersionDeclaration.ReplaceAll(data, []byte("${1}1.0${2}")), &query); err != nil {
+		return nil, nil, err
+	}
+	builder := newBazelGraphBuilder()
+	for _, rule := range query.Rules {
+		ruleInputs := make([]string, 0, len(rule.RuleInputs))
+		for _, ruleInput := range rule.RuleInputs {
+			ruleInputs = append(ruleInputs, ruleInput.Name)
+		}
+		builder.addRule(rule.Name, rule.Class, ruleInputs)
+	}
+	for _, sourceFile := range query.SourceFiles {
+		builder.addSourceFile(sourceFile.Name)
+	}
+	for _, generatedFile := range query.GeneratedFiles {
+		builder.addGeneratedFile(generatedFile.Name, generatedFile.GeneratingRule)
+	}
+	return builder.build()
+}
+
+// field numbers of the messages of Bazel's `build.proto` used when decoding `--output=proto`
+const (
+	protoQueryResultTarget           = 1
+	protoTargetRule                  = 2
+	protoTargetSourceFile            = 3
+	protoTargetGeneratedFile         = 4
+	protoRuleName                    = 1
+	protoRuleClass                   = 2
+	protoRuleInput                   = 5
+	protoSourceFileName              = 1
+	protoGeneratedFileName           = 1
+	protoGeneratedFileGeneratingRule = 2
+)
+
+const (
+	protoWireVarint  = 0
+	protoWireFixed64 = 1
+	protoWireBytes   = 2
+	protoWireFixed32 = 5
+)
+
+/*
+Iterate over fields of a serialized protobuf message calling `visit` for every length-delimited field;
+the fields of other wire types are skipped as none of them are needed to build the adjacency list.
+*/
+func readProtoMessage(data []byte, visit func(field int, value []byte) error) error {
+	for len(data) > 0 {
+		tag, size := binary.Uvarint(data)
+		if size <= 0 {
+			return errors.New("malformed protobuf field tag")
+		}
+		data = data[size:]
+		field, wireType := int(tag>>3), int(tag&7)
+
+		switch wireType {
+		case protoWireVarint:
+			_, size = binary.Uvarint(data)
+			if size <= 0 {
+				return errors.New("malformed protobuf varint")
+			}
+			data = data[size:]
+		case protoWireFixed64, protoWireFixed32:
+			width := 8
+			if wireType == protoWireFixed32 {
+				width = 4
+			}
+			if len(data) < width {
+				return errors.New("truncated protobuf message")
+			}
+			data = data[width:]
+		case protoWireBytes:
+			length, size := binary.Uvarint(data)
+			if size <= 0 || uint64(len(data)-size) < length {
+				return errors.New("truncated protobuf message")
+			}
+			if err := visit(field, data[size:size+int(length)]); err != nil {
+				return err
+			}
+			data = data[size+int(length):]
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", wireType)
+		}
+	}
+	return nil
+}
+
+// readProtoStrings collects values of given string fields of a serialized protobuf message
+func readProtoStrings(data []byte, fields ...int) (map[int][]string, error) {
+	values := make(map[int][]string)
+	err := readProtoMessage(data, func(field int, value []byte) error {
+		if slices.Contains(fields, field) {
+			values[field] = append(values[field], string(value))
+		}
+		return nil
+	})
+	return values, err
+}
+
+// addProtoTarget adds a serialized `Target` message to the graph
+func (builder *bazelGraphBuilder) addProtoTarget(target []byte) error {
+	return readProtoMessage(target, func(field int, message []byte) error {
+		first := func(values []string) string {
+			if len(values) == 0 {
+				return ""
+			}
+			return values[0]
+		}
+		switch field {
+		case protoTargetRule:
+			values, err := readProtoStrings(message, protoRuleName, protoRuleClass, protoRuleInput)
+			if err != nil {
+				return err
+			}
+			builder.addRule(first(values[protoRuleName]), first(values[protoRuleClass]), values[protoRuleInput])
+		case protoTargetSourceFile:
+			values, err := readProtoStrings(message, protoSourceFileName)
+			if err != nil {
+				return err
+			}
+			builder.addSourceFile(first(values[protoSourceFileName]))
+		case protoTargetGeneratedFile:
+			values, err := readProtoStrings(message, protoGeneratedFileName, protoGeneratedFileGeneratingRule)
+			if err != nil {
+				return err
+			}
+			builder.addGeneratedFile(first(values[protoGeneratedFileName]), first(values[protoGeneratedFileGeneratingRule]))
+		}
+		return nil
+	})
+}
+
+// loadBazelProtoFile loads output of `bazel query --output=proto`, i.e. a serialized `QueryResult` message
+func loadBazelProtoFile(data []byte) (AdjacencyList, NodeAttributes, error) {
+	builder := newBazelGraphBuilder()
+	err := readProtoMessage(data, func(field int, target []byte) error {
+		if field != protoQueryResultTarget {
+			return nil
+		}
+		return builder.addProtoTarget(target)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return builder.build()
+}
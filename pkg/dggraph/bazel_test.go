@@ -0,0 +1,158 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// protoField serializes a length-delimited protobuf field
+func protoField(field int, value []byte) []byte {
+	result := binary.AppendUvarint(nil, uint64(field<<3|protoWireBytes))
+	result = binary.AppendUvarint(result, uint64(len(value)))
+	return append(result, value...)
+}
+
+func protoMessage(fields ...[]byte) []byte {
+	result := []byte{}
+	for _, field := range fields {
+		result = append(result, field...)
+	}
+	return result
+}
+
+type testCaseBazel struct {
+	format             string
+	input              []byte
+	expected           AdjacencyList
+	expectedAttributes NodeAttributes
+}
+
+var bazelExpected = AdjacencyList{
+	"//app:main":     {"//lib:util", "//lib:gen"},
+	"//lib:util":     {"//lib:util.go"},
+	"//lib:util.go":  {},
+	"//lib:gen":      {"//lib:gen_rule"},
+	"//lib:gen_rule": {},
+}
+
+var bazelExpectedAttributes = NodeAttributes{
+	"//app:main":     {AttributeKind: "go_binary"},
+	"//lib:util":     {AttributeKind: "go_library"},
+	"//lib:util.go":  {AttributeKind: "source file"},
+	"//lib:gen":      {AttributeKind: "generated file"},
+	"//lib:gen_rule": {AttributeKind: "genrule"},
+}
+
+func TestLoadBazelFormats(t *testing.T) {
+	// varint and fixed fields (e.g. the target type) are skipped
+	targetType := []byte{1<<3 | protoWireVarint, 1}
+	protoOutput := protoMessage(
+		protoField(protoQueryResultTarget, protoMessage(targetType, protoField(protoTargetRule, protoMessage(
+			protoField(protoRuleName, []byte("//app:main")),
+			protoField(protoRuleClass, []byte("go_binary")),
+			protoField(3, []byte("/src/app/BUILD:1:1")),
+			protoField(protoRuleInput, []byte("//lib:util")),
+			protoField(protoRuleInput, []byte("//lib:gen")),
+		)))),
+		protoField(protoQueryResultTarget, protoField(protoTargetRule, protoMessage(
+			protoField(protoRuleName, []byte("//lib:util")),
+			protoField(protoRuleClass, []byte("go_library")),
+			protoField(protoRuleInput, []byte("//lib:util.go")),
+		))),
+		protoField(protoQueryResultTarget, protoField(protoTargetRule, protoMessage(
+			protoField(protoRuleName, []byte("//lib:gen_rule")),
+			protoField(protoRuleClass, []byte("genrule")),
+		))),
+		protoField(protoQueryResultTarget, protoField(protoTargetSourceFile, protoMessage(
+			protoField(protoSourceFileName, []byte("//lib:util.go")),
+		))),
+		protoField(protoQueryResultTarget, protoField(protoTargetGeneratedFile, protoMessage(
+			protoField(protoGeneratedFileName, []byte("//lib:gen")),
+			protoField(protoGeneratedFileGeneratingRule, []byte("//lib:gen_rule")),
+		))),
+	)
+
+	cases := []testCaseBazel{
+		{
+			format:             FormatBazelProto,
+			input:              protoOutput,
+			expected:           bazelExpected,
+			expectedAttributes: bazelExpectedAttributes,
+		},
+		{
+			format: FormatBazelJsonProto,
+			input: []byte(`
+{"type":"RULE","rule":{"name":"//app:main","ruleClass":"go_binary","ruleInput":["//lib:util","//lib:gen"]}}
+{"type":"RULE","rule":{"name":"//lib:util","ruleClass":"go_library","ruleInput":["//lib:util.go"]}}
+{"type":"RULE","rule":{"name":"//lib:gen_rule","ruleClass":"genrule"}}
+{"type":"SOURCE_FILE","sourceFile":{"name":"//lib:util.go","location":"/src/lib/util.go:1:1"}}
+{"type":"GENERATED_FILE","generatedFile":{"name":"//lib:gen","generatingRule":"//lib:gen_rule"}}
+`),
+			expected:           bazelExpected,
+			expectedAttributes: bazelExpectedAttributes,
+		},
+		{
+			format: FormatBazelXml,
+			input: []byte(`<?xml version="1.1" encoding="UTF-8" standalone="no"?>
+<query version="2">
+    <rule class="go_binary" location="/src/app/BUILD:1:1" name="//app:main">
+        <string name="name" value="main"/>
+        <rule-input name="//lib:util"/>
+        <rule-input name="//lib:gen"/>
+    </rule>
+    <rule class="go_library" location="/src/lib/BUILD:1:1" name="//lib:util">
+        <list name="srcs">
+            <label value="//lib:util.go"/>
+        </list>
+        <rule-input name="//lib:util.go"/>
+    </rule>
+    <rule class="genrule" location="/src/lib/BUILD:5:1" name="//lib:gen_rule"/>
+    <source-file location="/src/lib/util.go:1:1" name="//lib:util.go"/>
+    <generated-file generating-rule="//lib:gen_rule" location="/src/lib/BUILD:5:1" name="//lib:gen"/>
+</query>
+`),
+			expected:           bazelExpected,
+			expectedAttributes: bazelExpectedAttributes,
+		},
+		// grouped nodes are expanded
+		{
+			format: FormatBazelGraph,
+			input: []byte(`
+digraph mygraph {
+  node [shape=box];
+  "//app:main"
+  "//app:main" -> "//lib:a\n//lib:b"
+  "//lib:a\n//lib:b"
+  "//lib:a\n//lib:b" -> "//lib:c"
+  "//lib:c"
+}
+`),
+			expected: AdjacencyList{
+				"//app:main": {"//lib:a", "//lib:b"},
+				"//lib:a":    {"//lib:c"},
+				"//lib:b":    {"//lib:c"},
+				"//lib:c":    {},
+			},
+		},
+	}
+
+	for _, testCase := range cases {
+		adjacencyList, attributes, err := ParseAdjacencyList(testCase.input, testCase.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, adjacencyList, testCase.format)
+		assert.Equal(t, testCase.expectedAttributes, attributes, testCase.format)
+	}
+}
+
+func TestLoadBazelProtoInvalid(t *testing.T) {
+	// length-delimited field claiming more bytes than available
+	_, _, err := ParseAdjacencyList([]byte{protoQueryResultTarget<<3 | protoWireBytes, 10, 1}, FormatBazelProto)
+	assert.Error(t, err)
+}
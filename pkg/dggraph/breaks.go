@@ -0,0 +1,271 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"container/heap"
+	"math/bits"
+	"slices"
+	"strings"
+)
+
+// components of up to this many nodes get the exact minimum feedback arc set (exponential in their size)
+const exactFeedbackArcSetSize = 16
+
+// Edge is a dependency of a node on another node
+type Edge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// CycleBreak is an edge to remove to break cycles along with the number of cycles going through it
+type CycleBreak struct {
+	Edge
+	Cycles int `json:"cycles"`
+}
+
+/*
+FeedbackArcSet finds edges whose removal makes the graph acyclic trying to remove as few edges as possible
+(finding the minimum feedback arc set is NP-hard). Every strongly connected component is ordered so that
+few of its edges point backwards and these edges are removed: small components are ordered optimally
+and larger ones with the heuristic of Eades, Lin, and Smyth. Self-loops are always removed.
+Edges are sorted by the node and then by the dependency.
+*/
+func FeedbackArcSet(graph *Graph) []Edge {
+	edges := []Edge{}
+	components, _ := strongComponents(graph)
+	for _, component := range components {
+		if len(component) == 1 {
+			if slices.Contains(graph.Dependencies(component[0]), component[0]) {
+				name := graph.Name(component[0])
+				edges = append(edges, Edge{From: name, To: name})
+			}
+			continue
+		}
+		local := make(map[int]int, len(component))
+		for i, node := range component {
+			local[node] = i
+		}
+		// dependencies within the component without self-loops which are removed regardless of the order
+		dependencies := make([][]int, len(component))
+		for i, node := range component {
+			for _, dep := range graph.Dependencies(node) {
+				if j, exists := local[dep]; exists && j != i {
+					dependencies[i] = append(dependencies[i], j)
+				} else if exists {
+					edges = append(edges, Edge{From: graph.Name(node), To: graph.Name(node)})
+				}
+			}
+		}
+		var order []int
+		if len(component) <= exactFeedbackArcSetSize {
+			order = exactArcOrder(dependencies)
+		} else {
+			order = heuristicArcOrder(dependencies)
+		}
+		position := make([]int, len(order))
+		for i, node := range order {
+			position[node] = i
+		}
+		for i, deps := range dependencies {
+			for _, j := range deps {
+				if position[i] > position[j] {
+					edges = append(edges, Edge{From: graph.Name(component[i]), To: graph.Name(component[j])})
+				}
+			}
+		}
+	}
+	slices.SortFunc(edges, compareEdges)
+	return slices.Compact(edges)
+}
+
+func compareEdges(a Edge, b Edge) int {
+	if order := strings.Compare(a.From, b.From); order != 0 {
+		return order
+	}
+	return strings.Compare(a.To, b.To)
+}
+
+/*
+Order the nodes minimizing the number of edges pointing backwards with dynamic programming over
+subsets of nodes: the best order of a subset ends with the node whose edges to the rest of the subset
+(pointing backwards) add the least to the best order of the rest.
+*/
+func exactArcOrder(dependencies [][]int) []int {
+	size := len(dependencies)
+	depsMask := make([]uint32, size)
+	for i, deps := range dependencies {
+		for _, j := range deps {
+			depsMask[i] |= 1 << j
+		}
+	}
+	backwards := make([]int, 1<<size)
+	last := make([]int, 1<<size)
+	for mask := 1; mask < 1<<size; mask++ {
+		backwards[mask] = -1
+		for node := range size {
+			if mask&(1<<node) == 0 {
+				continue
+			}
+			rest := mask &^ (1 << node)
+			count := backwards[rest] + bits.OnesCount32(depsMask[node]&uint32(rest))
+			if backwards[mask] < 0 || count < backwards[mask] {
+				backwards[mask] = count
+				last[mask] = node
+			}
+		}
+	}
+	order := make([]int, size)
+	mask := 1<<size - 1
+	for i := size - 1; i >= 0; i-- {
+		order[i] = last[mask]
+		mask &^= 1 << last[mask]
+	}
+	return order
+}
+
+// deltaEntry is a node prioritized by the difference of its outgoing and incoming edges
+type deltaEntry struct {
+	node  int
+	delta int
+}
+
+// deltaHeap is a max-heap of nodes by their delta with ties broken by the smallest node
+type deltaHeap []deltaEntry
+
+func (h deltaHeap) Len() int { return len(h) }
+func (h deltaHeap) Less(i, j int) bool {
+	if h[i].delta != h[j].delta {
+		return h[i].delta > h[j].delta
+	}
+	return h[i].node < h[j].node
+}
+func (h deltaHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *deltaHeap) Push(x any)   { *h = append(*h, x.(deltaEntry)) }
+func (h *deltaHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+/*
+Order the nodes with the heuristic of Eades, Lin, and Smyth: sinks are repeatedly put at the end
+of the order and sources at the start; when there are neither, the node with the most outgoing edges
+compared to incoming edges is put at the start. Nodes are kept in a heap with stale entries skipped.
+*/
+func heuristicArcOrder(dependencies [][]int) []int {
+	size := len(dependencies)
+	dependents := make([][]int, size)
+	for i, deps := range dependencies {
+		for _, j := range deps {
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	outDegree := make([]int, size)
+	inDegree := make([]int, size)
+	for i := range size {
+		outDegree[i] = len(dependencies[i])
+		inDegree[i] = len(dependents[i])
+	}
+	removed := make([]bool, size)
+	sinks, sources := []int{}, []int{}
+	deltas := make(deltaHeap, 0, size)
+	for i := range size {
+		deltas = append(deltas, deltaEntry{node: i, delta: outDegree[i] - inDegree[i]})
+	}
+	heap.Init(&deltas)
+
+	start, end := []int{}, []int{}
+	remove := func(node int) {
+		removed[node] = true
+		for _, dep := range dependencies[node] {
+			if !removed[dep] {
+				inDegree[dep]--
+				if inDegree[dep] == 0 {
+					sources = append(sources, dep)
+				}
+				heap.Push(&deltas, deltaEntry{node: dep, delta: outDegree[dep] - inDegree[dep]})
+			}
+		}
+		for _, dependent := range dependents[node] {
+			if !removed[dependent] {
+				outDegree[dependent]--
+				if outDegree[dependent] == 0 {
+					sinks = append(sinks, dependent)
+				}
+				heap.Push(&deltas, deltaEntry{node: dependent, delta: outDegree[dependent] - inDegree[dependent]})
+			}
+		}
+	}
+	for len(start)+len(end) < size {
+		switch {
+		case len(sinks) > 0:
+			node := sinks[len(sinks)-1]
+			sinks = sinks[:len(sinks)-1]
+			if !removed[node] {
+				end = append(end, node)
+				remove(node)
+			}
+		case len(sources) > 0:
+			node := sources[len(sources)-1]
+			sources = sources[:len(sources)-1]
+			if !removed[node] {
+				start = append(start, node)
+				remove(node)
+			}
+		default:
+			entry := heap.Pop(&deltas).(deltaEntry)
+			if !removed[entry.node] && entry.delta == outDegree[entry.node]-inDegree[entry.node] {
+				start = append(start, entry.node)
+				remove(entry.node)
+			}
+		}
+	}
+	// sinks were removed last to first
+	slices.Reverse(end)
+	return append(start, end...)
+}
+
+/*
+SuggestBreaks finds edges to remove to make the graph acyclic (see FeedbackArcSet) ranked by the number
+of cycles going through them, most first. Cycles are found with given options, so if the search
+of cycles is not complete, the numbers of cycles are lower bounds.
+*/
+func SuggestBreaks(graph *Graph, options CycleOptions) ([]CycleBreak, bool, error) {
+	cycles, complete, err := Cycles(graph, options)
+	if err != nil {
+		return nil, false, err
+	}
+	counts := make(map[Edge]int)
+	for _, cycle := range cycles {
+		for i, node := range cycle {
+			counts[Edge{From: node, To: cycle[(i+1)%len(cycle)]}]++
+		}
+	}
+	breaks := []CycleBreak{}
+	for _, edge := range FeedbackArcSet(graph) {
+		breaks = append(breaks, CycleBreak{Edge: edge, Cycles: counts[edge]})
+	}
+	// the sort is stable, so edges through the same number of cycles stay sorted
+	slices.SortStableFunc(breaks, func(a CycleBreak, b CycleBreak) int {
+		return b.Cycles - a.Cycles
+	})
+	return breaks, complete, nil
+}
+
+// RemoveEdges gets the adjacency list of the graph without given edges
+func RemoveEdges(graph *Graph, edges []Edge) AdjacencyList {
+	removed := make(map[Edge]bool, len(edges))
+	for _, edge := range edges {
+		removed[edge] = true
+	}
+	result := graph.AdjacencyList()
+	for node, deps := range result {
+		result[node] = slices.DeleteFunc(deps, func(dep string) bool {
+			return removed[Edge{From: node, To: dep}]
+		})
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// assertAcyclic checks that removing the edges from the graph leaves no cycles
+func assertAcyclic(t *testing.T, graph *Graph, edges []Edge) {
+	cycles, complete, err := Cycles(NewGraph(RemoveEdges(graph, edges)), CycleOptions{MaxCycles: 1})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Empty(t, cycles)
+}
+
+func TestSuggestBreaks(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"a": {"b"},
+		"b": {"a", "c"},
+		"c": {"a"},
+		"d": {"d", "e"},
+		"e": {},
+	})
+	// both cycles of a, b, and c go through the edge from a to b
+	breaks, complete, err := SuggestBreaks(graph, CycleOptions{})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, []CycleBreak{
+		{Edge: Edge{From: "a", To: "b"}, Cycles: 2},
+		{Edge: Edge{From: "d", To: "d"}, Cycles: 1},
+	}, breaks)
+	assert.Equal(t, AdjacencyList{"a": {}, "b": {"a", "c"}, "c": {"a"}, "d": {"e"}, "e": {}}, RemoveEdges(graph, []Edge{breaks[0].Edge, breaks[1].Edge}))
+
+	// there is nothing to break in an acyclic graph
+	assert.Equal(t, []Edge{}, FeedbackArcSet(NewGraph(Condense(testGraph))))
+}
+
+func TestFeedbackArcSetLargeComponents(t *testing.T) {
+	// every order of a complete graph has half of the edges pointing backwards
+	graph := completeGraph(exactFeedbackArcSetSize + 4)
+	edges := FeedbackArcSet(graph)
+	assert.Len(t, edges, (exactFeedbackArcSetSize+4)*(exactFeedbackArcSetSize+3)/2)
+	assertAcyclic(t, graph, edges)
+
+	// a long cycle with shortcuts back to its start is broken by removing a single edge
+	adjacencyList := make(AdjacencyList)
+	size := 100000
+	for i := range size {
+		adjacencyList.AddEdge(fmt.Sprintf("node-%06d", i), fmt.Sprintf("node-%06d", (i+1)%size))
+		if i%1000 == 999 {
+			adjacencyList.AddEdge(fmt.Sprintf("node-%06d", i), "node-000000")
+		}
+	}
+	graph = NewGraph(adjacencyList)
+	edges = FeedbackArcSet(graph)
+	assert.Len(t, edges, 1)
+	assertAcyclic(t, graph, edges)
+}
+
+func TestExactArcOrder(t *testing.T) {
+	// two cycles sharing the edge from 0 to 1 which is the only edge to point backwards
+	dependencies := [][]int{{1}, {2, 3}, {0}, {0}}
+	order := exactArcOrder(dependencies)
+	assert.Equal(t, 0, order[len(order)-1])
+	assert.Equal(t, 1, order[0])
+}
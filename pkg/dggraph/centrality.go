@@ -0,0 +1,145 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+const (
+	// probability of following a dependency rather than jumping to any node in PageRank
+	pageRankDamping = 0.85
+	// PageRank iterations stop once ranks change by less than this in total (or after the maximum iterations)
+	pageRankTolerance     = 1e-10
+	pageRankMaxIterations = 100
+)
+
+/*
+Betweenness gets the betweenness centrality of every node, i.e. the number of shortest chains of dependencies
+between other nodes going through the node, with Brandes' algorithm. Nodes with high betweenness are chokepoints
+many parts of the graph depend on through. The algorithm searches from every node which may take too long on a large
+graph, so with samples set to a positive number less than the number of nodes, the searches are done only from
+this many randomly chosen nodes (always the same for the same graph) and the values are scaled accordingly.
+*/
+func Betweenness(graph *Graph, samples int) map[string]float64 {
+	size := graph.Size()
+	sources := make([]int, size)
+	for i := range sources {
+		sources[i] = i
+	}
+	scale := 1.0
+	if samples > 0 && samples < size {
+		// a fixed seed so that the same nodes are sampled every time
+		random := rand.New(rand.NewPCG(uint64(size), uint64(samples)))
+		sources = random.Perm(size)[:samples]
+		scale = float64(size) / float64(samples)
+	}
+
+	betweenness := make([]float64, size)
+	// number of shortest chains from the source, distance from the source, and nodes preceding on the shortest chains
+	paths := make([]float64, size)
+	distance := make([]int, size)
+	predecessors := make([][]int, size)
+	dependency := make([]float64, size)
+	order := make([]int, 0, size)
+	for _, source := range sources {
+		for i := range size {
+			paths[i], distance[i], dependency[i] = 0, -1, 0
+			predecessors[i] = predecessors[i][:0]
+		}
+		paths[source], distance[source] = 1, 0
+		order = append(order[:0], source)
+		for next := 0; next < len(order); next++ {
+			node := order[next]
+			for _, dep := range graph.Dependencies(node) {
+				if distance[dep] < 0 {
+					distance[dep] = distance[node] + 1
+					order = append(order, dep)
+				}
+				if distance[dep] == distance[node]+1 {
+					paths[dep] += paths[node]
+					predecessors[dep] = append(predecessors[dep], node)
+				}
+			}
+		}
+		// nodes are visited in order of decreasing distance to accumulate the dependencies of the source
+		for i := len(order) - 1; i > 0; i-- {
+			node := order[i]
+			for _, predecessor := range predecessors[node] {
+				dependency[predecessor] += paths[predecessor] / paths[node] * (1 + dependency[node])
+			}
+			betweenness[node] += dependency[node]
+		}
+	}
+
+	result := make(map[string]float64, size)
+	for id, value := range betweenness {
+		result[graph.Name(id)] = value * scale
+	}
+	return result
+}
+
+/*
+PageRank ranks nodes following dependencies: a node ranks high if it's a dependency of many nodes that rank high
+themselves, so the ranks of all nodes add up to 1. The rank of the nodes without dependencies is spread
+across all nodes.
+*/
+func PageRank(graph *Graph) map[string]float64 {
+	size := graph.Size()
+	result := make(map[string]float64, size)
+	if size == 0 {
+		return result
+	}
+	ranks := make([]float64, size)
+	for i := range ranks {
+		ranks[i] = 1 / float64(size)
+	}
+	next := make([]float64, size)
+	for range pageRankMaxIterations {
+		leavesRank := 0.0
+		for node := range size {
+			if len(graph.Dependencies(node)) == 0 {
+				leavesRank += ranks[node]
+			}
+		}
+		change := 0.0
+		for node := range size {
+			rank := leavesRank / float64(size)
+			for _, dependent := range graph.Dependents(node) {
+				rank += ranks[dependent] / float64(len(graph.Dependencies(dependent)))
+			}
+			next[node] = (1-pageRankDamping)/float64(size) + pageRankDamping*rank
+			change += math.Abs(next[node] - ranks[node])
+		}
+		ranks, next = next, ranks
+		if change < pageRankTolerance {
+			break
+		}
+	}
+	for id, rank := range ranks {
+		result[graph.Name(id)] = rank
+	}
+	return result
+}
+
+/*
+Instability gets the instability of every node as defined by Robert C. Martin: I = Ce / (Ca + Ce) where
+the efferent coupling Ce is the number of dependencies and the afferent coupling Ca is the number of dependents.
+Nodes with the instability of 0 are only depended on (and are hard to change) and nodes with the instability of 1
+only depend on other nodes; nodes with neither dependencies nor dependents have the instability of 0.
+*/
+func Instability(graph *Graph) map[string]float64 {
+	result := make(map[string]float64, graph.Size())
+	for id := range graph.Size() {
+		efferent := len(graph.Dependencies(id))
+		afferent := len(graph.Dependents(id))
+		instability := 0.0
+		if efferent+afferent > 0 {
+			instability = float64(efferent) / float64(efferent+afferent)
+		}
+		result[graph.Name(id)] = instability
+	}
+	return result
+}
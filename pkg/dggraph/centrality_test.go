@@ -0,0 +1,68 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBetweenness(t *testing.T) {
+	// every chain from the applications to the other libraries goes through lib/core.py
+	graph := NewGraph(AdjacencyList{
+		"app/a.py":    {"lib/core.py"},
+		"app/b.py":    {"lib/core.py"},
+		"lib/core.py": {"lib/log.py", "lib/db.py"},
+	})
+	assert.Equal(t, map[string]float64{
+		"app/a.py": 0, "app/b.py": 0, "lib/core.py": 4, "lib/log.py": 0, "lib/db.py": 0,
+	}, Betweenness(graph, 0))
+
+	// two shortest chains from a to d share the betweenness
+	graph = NewGraph(AdjacencyList{"a": {"b", "c"}, "b": {"d"}, "c": {"d"}})
+	assert.Equal(t, map[string]float64{"a": 0, "b": 0.5, "c": 0.5, "d": 0}, Betweenness(graph, 0))
+}
+
+func TestBetweennessSampling(t *testing.T) {
+	// a chain where the nodes in the middle are on the most chains
+	adjacencyList := make(AdjacencyList)
+	size := 200
+	for i := range size - 1 {
+		adjacencyList.AddEdge(fmt.Sprintf("node-%03d", i), fmt.Sprintf("node-%03d", i+1))
+	}
+	graph := NewGraph(adjacencyList)
+	exact := Betweenness(graph, 0)
+	assert.Equal(t, float64(99*100), exact["node-100"])
+
+	sampled := Betweenness(graph, 50)
+	assert.Equal(t, sampled, Betweenness(graph, 50))
+	assert.InEpsilon(t, exact["node-100"], sampled["node-100"], 0.5)
+	assert.Equal(t, 0.0, sampled["node-000"])
+}
+
+func TestPageRank(t *testing.T) {
+	ranks := PageRank(testGraph)
+	total := 0.0
+	for _, rank := range ranks {
+		total += rank
+	}
+	assert.InDelta(t, 1, total, 1e-9)
+	// the logging library is used by everything
+	for node, rank := range ranks {
+		if node != "lib/log.py" {
+			assert.Greater(t, ranks["lib/log.py"], rank)
+		}
+	}
+	assert.InDelta(t, ranks["app/cli.py"], ranks["app/main.py"], 1e-12)
+	assert.Empty(t, PageRank(NewGraph(AdjacencyList{})))
+}
+
+func TestInstability(t *testing.T) {
+	assert.Equal(t, map[string]float64{
+		"app/cli.py": 1, "app/main.py": 1, "lib/db.py": 0.5, "lib/orm.py": 0.5, "lib/log.py": 0,
+	}, Instability(testGraph))
+	assert.Equal(t, map[string]float64{"foo.py": 0}, Instability(NewGraph(AdjacencyList{"foo.py": {}})))
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+/*
+Heights gets the length (in edges) of the longest chain of dependencies under every node; leaves have
+the height of 0. Chains are measured on the condensation of the graph (see Condense), so all nodes
+of a strongly connected component have the height of the component.
+*/
+func Heights(graph *Graph) map[string]int {
+	components, componentOf := strongComponents(graph)
+	return componentValues(graph, components, componentHeights(graph, components, componentOf))
+}
+
+/*
+Depths gets the length (in edges) of the longest chain of dependents above every node, i.e. the longest
+chain from any root down to the node; roots have the depth of 0. Chains are measured on the condensation
+of the graph (see Condense), so all nodes of a strongly connected component have the depth of the component.
+*/
+func Depths(graph *Graph) map[string]int {
+	components, componentOf := strongComponents(graph)
+	depths := make([]int, len(components))
+	// components are listed after the components they depend on, so dependents are visited first
+	for i := len(components) - 1; i >= 0; i-- {
+		for _, node := range components[i] {
+			for _, dependent := range graph.Dependents(node) {
+				if other := componentOf[dependent]; other != i {
+					depths[i] = max(depths[i], depths[other]+1)
+				}
+			}
+		}
+	}
+	return componentValues(graph, components, depths)
+}
+
+/*
+CriticalPath gets the longest chain of dependencies in the graph from a root down to a leaf (the chain
+that would take the longest to build if nodes of the chain can only be built one after another).
+Chains are found on the condensation of the graph, so a strongly connected component is a single node
+of the chain named as the condensed node (see Condense). Of the chains of the same length,
+the one with the smallest nodes is returned.
+*/
+func CriticalPath(graph *Graph) []string {
+	components, componentOf := strongComponents(graph)
+	heights := componentHeights(graph, components, componentOf)
+	names := condensedNames(graph, components)
+	path := []string{}
+	current := -1
+	for i, component := range components {
+		if current < 0 || heights[i] > heights[current] || (heights[i] == heights[current] && component[0] < components[current][0]) {
+			current = i
+		}
+	}
+	for current >= 0 {
+		path = append(path, names[current])
+		next := -1
+		for _, node := range components[current] {
+			for _, dep := range graph.Dependencies(node) {
+				other := componentOf[dep]
+				if other != current && heights[other] == heights[current]-1 && (next < 0 || components[other][0] < components[next][0]) {
+					next = other
+				}
+			}
+		}
+		current = next
+	}
+	return path
+}
+
+// componentHeights gets the heights of the strongly connected components in the condensation
+func componentHeights(graph *Graph, components [][]int, componentOf []int) []int {
+	heights := make([]int, len(components))
+	// components are listed after the components they depend on, so dependencies are visited first
+	for i, component := range components {
+		for _, node := range component {
+			for _, dep := range graph.Dependencies(node) {
+				if other := componentOf[dep]; other != i {
+					heights[i] = max(heights[i], heights[other]+1)
+				}
+			}
+		}
+	}
+	return heights
+}
+
+// componentValues assigns the value of every strongly connected component to its nodes
+func componentValues(graph *Graph, components [][]int, values []int) map[string]int {
+	result := make(map[string]int, graph.Size())
+	for i, component := range components {
+		for _, node := range component {
+			result[graph.Name(node)] = values[i]
+		}
+	}
+	return result
+}
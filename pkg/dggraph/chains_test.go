@@ -0,0 +1,41 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChains(t *testing.T) {
+	// lib/db.py and lib/orm.py depend on each other, so they are a single node of the chains
+	assert.Equal(t, map[string]int{
+		"app/cli.py": 1, "app/main.py": 2, "lib/db.py": 1, "lib/orm.py": 1, "lib/log.py": 0,
+	}, Heights(testGraph))
+	assert.Equal(t, map[string]int{
+		"app/cli.py": 0, "app/main.py": 0, "lib/db.py": 1, "lib/orm.py": 1, "lib/log.py": 2,
+	}, Depths(testGraph))
+	assert.Equal(t, []string{"app/main.py", "lib/db.py|lib/orm.py", "lib/log.py"}, CriticalPath(testGraph))
+
+	// of the chains of the same length, the one with the smallest nodes is chosen
+	graph := NewGraph(AdjacencyList{
+		"b": {"d", "c"},
+		"a": {"e"},
+		"c": {"f"},
+		"d": {"f"},
+		"e": {"f"},
+	})
+	assert.Equal(t, []string{"a", "e", "f"}, CriticalPath(graph))
+	assert.Equal(t, []string{}, CriticalPath(NewGraph(AdjacencyList{})))
+
+	// components are named as their condensed nodes, which cannot collide with names of other nodes
+	graph = NewGraph(AdjacencyList{
+		"a":   {"b"},
+		"b":   {"a", "a|b"},
+		"a|b": {"c"},
+	})
+	assert.Equal(t, []string{"a|b#2", "a|b", "c"}, CriticalPath(graph))
+	assert.Contains(t, Condense(graph), "a|b#2")
+}
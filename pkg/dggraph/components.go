@@ -0,0 +1,142 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import "slices"
+
+// ConnectedComponents finds (weakly) connected components in a graph; components and their nodes are sorted
+func ConnectedComponents(graph *Graph) [][]string {
+	visitedNodes := make([]bool, graph.Size())
+	// initialize to an empty slice (returned for an empty graph)
+	connectedComponents := make([][]string, 0)
+
+	// Find components starting from each unvisited node; as nodes are visited in the order
+	// of their IDs, components are sorted by their first node. Both dependencies and dependents
+	// are followed since when node A is connected to B, B is connected to A as well.
+	for node := range graph.Size() {
+		if visitedNodes[node] {
+			continue
+		}
+		visitedNodes[node] = true
+		component := []int{node}
+		stack := []int{node}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, neighbors := range [][]int{graph.Dependencies(current), graph.Dependents(current)} {
+				for _, neighbor := range neighbors {
+					if !visitedNodes[neighbor] {
+						visitedNodes[neighbor] = true
+						component = append(component, neighbor)
+						stack = append(stack, neighbor)
+					}
+				}
+			}
+		}
+		slices.Sort(component)
+		connectedComponents = append(connectedComponents, graph.ToNames(component))
+	}
+	return connectedComponents
+}
+
+/*
+Find strongly connected components with Tarjan's algorithm (iterative so that long dependency chains
+do not overflow the stack). Components are returned in reverse topological order of the condensation,
+i.e. a component is listed after all components it depends on, with nodes of every component sorted;
+the component of every node is returned as well.
+*/
+func strongComponents(graph *Graph) ([][]int, []int) {
+	return tarjan(graph.Size(), graph.Dependencies)
+}
+
+// tarjan finds strongly connected components of a graph of nodes from 0 to size-1 with given dependencies
+func tarjan(size int, dependencies func(id int) []int) ([][]int, []int) {
+	// order in which nodes are discovered starting from 1 (0 for nodes not discovered yet)
+	discovered := make([]int, size)
+	lowLink := make([]int, size)
+	onStack := make([]bool, size)
+	componentOf := make([]int, size)
+	components := [][]int{}
+	stack := []int{}
+	counter := 0
+
+	// the recursion of the algorithm is replaced with frames of the nodes being visited
+	type frame struct {
+		node int
+		next int
+	}
+	discover := func(node int) frame {
+		counter++
+		discovered[node] = counter
+		lowLink[node] = counter
+		stack = append(stack, node)
+		onStack[node] = true
+		return frame{node: node}
+	}
+
+	for root := range size {
+		if discovered[root] != 0 {
+			continue
+		}
+		frames := []frame{discover(root)}
+		for len(frames) > 0 {
+			current := &frames[len(frames)-1]
+			node := current.node
+			if deps := dependencies(node); current.next < len(deps) {
+				dep := deps[current.next]
+				current.next++
+				if discovered[dep] == 0 {
+					frames = append(frames, discover(dep))
+				} else if onStack[dep] {
+					lowLink[node] = min(lowLink[node], discovered[dep])
+				}
+				continue
+			}
+
+			// all dependencies are visited, so the node is done
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].node
+				lowLink[parent] = min(lowLink[parent], lowLink[node])
+			}
+			if lowLink[node] == discovered[node] {
+				component := []int{}
+				for {
+					member := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[member] = false
+					componentOf[member] = len(components)
+					component = append(component, member)
+					if member == node {
+						break
+					}
+				}
+				slices.Sort(component)
+				components = append(components, component)
+			}
+		}
+	}
+	return components, componentOf
+}
+
+/*
+StronglyConnectedComponents finds components in which every node can reach every other node
+(following dependencies) with nodes of every component sorted; the largest components come first
+and components of the same size are sorted by their first node. Every node that is not part
+of a cycle is a component on its own.
+*/
+func StronglyConnectedComponents(graph *Graph) [][]string {
+	components, _ := strongComponents(graph)
+	slices.SortFunc(components, func(a []int, b []int) int {
+		if len(a) != len(b) {
+			return len(b) - len(a)
+		}
+		return a[0] - b[0]
+	})
+	result := make([][]string, len(components))
+	for i, component := range components {
+		result[i] = graph.ToNames(component)
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"a": {"b"},
+		"b": {"c", "e"},
+		"c": {"a", "d"},
+		"d": {"d"},
+		"e": {"f"},
+		"f": {"e", "g"},
+	})
+	assert.Equal(t, [][]string{{"a", "b", "c"}, {"e", "f"}, {"d"}, {"g"}}, StronglyConnectedComponents(graph))
+	assert.Equal(t, [][]string{}, StronglyConnectedComponents(NewGraph(AdjacencyList{})))
+
+	// components are listed after the components they depend on
+	components, componentOf := strongComponents(graph)
+	for i, component := range components {
+		for _, node := range component {
+			for _, dep := range graph.Dependencies(node) {
+				assert.LessOrEqual(t, componentOf[dep], i)
+			}
+		}
+	}
+}
+
+func TestStronglyConnectedComponentsLongChain(t *testing.T) {
+	// a single cycle through all nodes which would need deep recursion
+	adjacencyList := make(AdjacencyList)
+	size := 100000
+	for i := range size {
+		adjacencyList[fmt.Sprintf("node-%06d", i)] = []string{fmt.Sprintf("node-%06d", (i+1)%size)}
+	}
+	components := StronglyConnectedComponents(NewGraph(adjacencyList))
+	assert.Len(t, components, 1)
+	assert.Len(t, components[0], size)
+}
+
+func TestCondense(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"app": {"a", "log"},
+		"a":   {"b"},
+		"b":   {"a", "log"},
+		"log": {"log"},
+		"cli": {"ext"},
+	})
+	condensed := Condense(graph)
+	assert.Equal(t, AdjacencyList{
+		"app": {"a|b", "log"},
+		"a|b": {"log"},
+		"log": {},
+		"cli": {"ext"},
+	}, condensed)
+
+	// the condensation is acyclic and can be used as any other graph
+	cycles, complete, err := Cycles(NewGraph(condensed), CycleOptions{})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Equal(t, [][]string{}, cycles)
+	assert.Equal(t, "a|b", condensedNodeName([]string{"a", "b"}))
+
+	// names of components cannot collide with names of nodes or of other components
+	condensed = Condense(NewGraph(AdjacencyList{
+		"a":   {"b"},
+		"b":   {"a", "a|b"},
+		"a|b": {"x|y", "x"},
+		"x|y": {"z"},
+		"z":   {"x|y"},
+		"x":   {"y|z"},
+		"y|z": {"x"},
+	}))
+	assert.Equal(t, AdjacencyList{
+		"a|b#2":   {"a|b"},
+		"a|b":     {"x|y|z", "x|y|z#2"},
+		"x|y|z":   {},
+		"x|y|z#2": {},
+	}, condensed)
+}
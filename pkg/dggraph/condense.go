@@ -0,0 +1,80 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// separator of the names of nodes of a strongly connected component in the name of its condensed node
+const CondensedNodeSeparator = "|"
+
+// condensedNodeName joins the names of the nodes of a strongly connected component (see condensedNames for unique names)
+func condensedNodeName(component []string) string {
+	return strings.Join(component, CondensedNodeSeparator)
+}
+
+/*
+Get the names of the condensed nodes of the components. As the separator may be part of node names, the name
+of a component of several nodes that is already taken by a node of the graph or by another component
+(e.g. "a|b" for both ["a", "b"] and a node named "a|b") gets the smallest free suffix "#2", "#3", etc.
+*/
+func condensedNames(graph *Graph, components [][]int) []string {
+	names := make([]string, len(components))
+	taken := make(map[string]bool, len(components))
+	// nodes that are not part of a cycle keep their names, so they're named first
+	for i, component := range components {
+		if len(component) == 1 {
+			names[i] = graph.Name(component[0])
+			taken[names[i]] = true
+		}
+	}
+	for i, component := range components {
+		if len(component) == 1 {
+			continue
+		}
+		joined := condensedNodeName(graph.ToNames(component))
+		name := joined
+		for suffix := 2; taken[name]; suffix++ {
+			name = fmt.Sprintf("%s#%d", joined, suffix)
+		}
+		names[i] = name
+		taken[name] = true
+	}
+	return names
+}
+
+/*
+Condense the graph into the directed acyclic graph of its strongly connected components: every component
+becomes a single node named after its sorted nodes joined with CondensedNodeSeparator (so nodes that are
+not part of a cycle keep their names and names are unique, see condensedNames) and depends on the components
+its nodes depend on. A component is a key of the adjacency list if any of its nodes is a key of the adjacency
+list of the graph.
+*/
+func Condense(graph *Graph) AdjacencyList {
+	components, componentOf := strongComponents(graph)
+	names := condensedNames(graph, components)
+
+	result := make(AdjacencyList)
+	for i, component := range components {
+		declared := false
+		deps := []string{}
+		for _, node := range component {
+			declared = declared || graph.IsDeclared(node)
+			for _, dep := range graph.Dependencies(node) {
+				// edges within the component (including self-loops) are dropped
+				if componentOf[dep] != i {
+					deps = append(deps, names[componentOf[dep]])
+				}
+			}
+		}
+		if declared {
+			slices.Sort(deps)
+			result[names[i]] = slices.Compact(deps)
+		}
+	}
+	return result
+}
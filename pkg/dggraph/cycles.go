@@ -0,0 +1,299 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"slices"
+	"time"
+)
+
+// CycleOptions limits the cycles to find and the work done finding them
+type CycleOptions struct {
+	// maximum number of nodes in a cycle; 0 for no limit
+	MaxLength int
+	// maximum number of cycles to find; 0 for no limit
+	MaxCycles int
+	// only cycles going through any of these nodes (which may be patterns); all cycles if empty
+	Through []string
+	// maximum time to spend finding cycles; 0 for no limit
+	Timeout time.Duration
+}
+
+/*
+Cycles finds elementary cycles in the graph with every cycle starting from its smallest node and cycles sorted.
+Cycles are enumerated with Johnson's algorithm within every strongly connected component (as there are no cycles
+between components); a cycle going through any of the nodes to search through is looked for starting from these nodes.
+The search stops once the maximum number of cycles is found or the timeout expires, in which case the cycles found
+so far are returned and the search is reported as not complete.
+*/
+func Cycles(graph *Graph, options CycleOptions) ([][]string, bool, error) {
+	search := &cycleSearch{
+		graph:     graph,
+		options:   options,
+		allowed:   make([]bool, graph.Size()),
+		blocked:   make([]bool, graph.Size()),
+		blockedBy: make([]map[int]bool, graph.Size()),
+		distance:  make([]int, graph.Size()),
+	}
+	if options.Timeout > 0 {
+		search.deadline = time.Now().Add(options.Timeout)
+	}
+
+	components, componentOf := strongComponents(graph)
+	if len(options.Through) == 0 {
+		// cycles through the smallest node of a component are found first, then the node is removed
+		// and cycles are found in the strongly connected components of the remaining nodes
+		pending := components
+		for len(pending) > 0 && !search.stopped {
+			component := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if search.hasCycle(component) {
+				search.find(component[0], component)
+				pending = append(pending, search.subcomponents(component[1:])...)
+			}
+		}
+	} else {
+		through, err := ExpandPatterns(graph.Nodes(), options.Through)
+		if err != nil {
+			return nil, false, err
+		}
+		ids := graph.ToIds(through)
+		slices.Sort(ids)
+		ids = slices.Compact(ids)
+		// cycles through the nodes searched before are excluded as they have been found already
+		searched := make([]bool, graph.Size())
+		for _, start := range ids {
+			if search.stopped {
+				break
+			}
+			remaining := slices.DeleteFunc(slices.Clone(components[componentOf[start]]), func(node int) bool {
+				return searched[node]
+			})
+			for _, component := range search.subcomponents(remaining) {
+				if slices.Contains(component, start) && search.hasCycle(component) {
+					search.find(start, component)
+				}
+			}
+			searched[start] = true
+		}
+	}
+
+	result := make([][]string, len(search.cycles))
+	for i, cycle := range search.cycles {
+		// rotate the cycle to start from its smallest node
+		smallest := slices.Index(cycle, slices.Min(cycle))
+		result[i] = graph.ToNames(append(cycle[smallest:], cycle[:smallest]...))
+	}
+	slices.SortFunc(result, slices.Compare)
+	return result, !search.stopped, nil
+}
+
+// cycleSearch keeps the state of the search of cycles allocated once for all start nodes
+type cycleSearch struct {
+	graph    *Graph
+	options  CycleOptions
+	deadline time.Time
+	stopped  bool
+	steps    int
+	cycles   [][]int
+
+	start int
+	path  []int
+	// nodes of the strongly connected component being searched
+	allowed []bool
+	// nodes that cannot lead back to the start node (until a node they depend on is unblocked)
+	blocked []bool
+	// sets of the blocked nodes to unblock along with a node
+	blockedBy []map[int]bool
+	// number of dependencies from a node to the start node used to bound the length of cycles
+	distance []int
+}
+
+// shouldStop checks the limits of the search; the clock is checked only every so often as it's relatively slow
+func (search *cycleSearch) shouldStop() bool {
+	if search.stopped {
+		return true
+	}
+	if search.options.MaxCycles > 0 && len(search.cycles) >= search.options.MaxCycles {
+		search.stopped = true
+	}
+	search.steps++
+	if !search.deadline.IsZero() && search.steps%1024 == 0 && time.Now().After(search.deadline) {
+		search.stopped = true
+	}
+	return search.stopped
+}
+
+// hasCycle checks if a strongly connected component has a cycle, i.e. it has more than one node or a self-loop
+func (search *cycleSearch) hasCycle(component []int) bool {
+	return len(component) > 1 || slices.Contains(search.graph.Dependencies(component[0]), component[0])
+}
+
+// subcomponents finds strongly connected components of the subgraph of given sorted nodes
+func (search *cycleSearch) subcomponents(nodes []int) [][]int {
+	local := make(map[int]int, len(nodes))
+	for i, node := range nodes {
+		local[node] = i
+	}
+	dependencies := make([][]int, len(nodes))
+	for i, node := range nodes {
+		for _, dep := range search.graph.Dependencies(node) {
+			if j, exists := local[dep]; exists {
+				dependencies[i] = append(dependencies[i], j)
+			}
+		}
+	}
+	components, _ := tarjan(len(nodes), func(id int) []int { return dependencies[id] })
+	// local IDs are in the order of the nodes, so the components stay sorted
+	for _, component := range components {
+		for i, id := range component {
+			component[i] = nodes[id]
+		}
+	}
+	return components
+}
+
+// find cycles going through the start node within its strongly connected component
+func (search *cycleSearch) find(start int, component []int) {
+	for _, node := range component {
+		search.allowed[node] = true
+		search.blocked[node] = false
+		clear(search.blockedBy[node])
+	}
+	defer func() {
+		for _, node := range component {
+			search.allowed[node] = false
+		}
+	}()
+	search.start = start
+	search.path = search.path[:0]
+	if search.options.MaxLength == 0 {
+		search.circuit()
+		return
+	}
+
+	// distances to the start node are found with the breadth-first search following dependents
+	for _, node := range component {
+		search.distance[node] = -1
+	}
+	search.distance[start] = 0
+	queue := []int{start}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for _, dependent := range search.graph.Dependents(node) {
+			if search.allowed[dependent] && search.distance[dependent] < 0 {
+				search.distance[dependent] = search.distance[node] + 1
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	search.boundedCircuit(start)
+}
+
+func (search *cycleSearch) addCycle() {
+	search.cycles = append(search.cycles, slices.Clone(search.path))
+}
+
+// circuit is the search of Johnson's algorithm from the start node
+func (search *cycleSearch) circuit() {
+	// the recursion of the algorithm is replaced with frames of the nodes on the path
+	type frame struct {
+		node int
+		next int
+		// any cycle was found from the node
+		found bool
+	}
+	visit := func(node int) frame {
+		search.path = append(search.path, node)
+		search.blocked[node] = true
+		return frame{node: node}
+	}
+
+	frames := []frame{visit(search.start)}
+	for len(frames) > 0 {
+		current := &frames[len(frames)-1]
+		node := current.node
+		deps := search.graph.Dependencies(node)
+		if current.next < len(deps) {
+			if search.shouldStop() {
+				return
+			}
+			dep := deps[current.next]
+			current.next++
+			if !search.allowed[dep] {
+				continue
+			}
+			if dep == search.start {
+				search.addCycle()
+				current.found = true
+			} else if !search.blocked[dep] {
+				frames = append(frames, visit(dep))
+			}
+			continue
+		}
+
+		// all dependencies are visited, so the node is done
+		found := current.found
+		frames = frames[:len(frames)-1]
+		search.path = search.path[:len(search.path)-1]
+		if found {
+			search.unblock(node)
+			if len(frames) > 0 {
+				frames[len(frames)-1].found = true
+			}
+			continue
+		}
+		// the node stays blocked until any of its dependencies is unblocked
+		for _, dep := range deps {
+			if !search.allowed[dep] {
+				continue
+			}
+			if search.blockedBy[dep] == nil {
+				search.blockedBy[dep] = map[int]bool{}
+			}
+			search.blockedBy[dep][node] = true
+		}
+	}
+}
+
+func (search *cycleSearch) unblock(node int) {
+	stack := []int{node}
+	search.blocked[node] = false
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for other := range search.blockedBy[current] {
+			if search.blocked[other] {
+				search.blocked[other] = false
+				stack = append(stack, other)
+			}
+		}
+		clear(search.blockedBy[current])
+	}
+}
+
+/*
+Find cycles of bounded length with a depth-first search; blocking nodes as Johnson's algorithm does is not
+correct when the length is bounded, so paths are pruned by the distance back to the start node instead.
+*/
+func (search *cycleSearch) boundedCircuit(node int) {
+	search.path = append(search.path, node)
+	search.blocked[node] = true
+	for _, dep := range search.graph.Dependencies(node) {
+		if search.shouldStop() {
+			break
+		}
+		if !search.allowed[dep] {
+			continue
+		}
+		if dep == search.start {
+			search.addCycle()
+		} else if !search.blocked[dep] && len(search.path)+search.distance[dep] <= search.options.MaxLength {
+			search.boundedCircuit(dep)
+		}
+	}
+	search.blocked[node] = false
+	search.path = search.path[:len(search.path)-1]
+}
@@ -0,0 +1,97 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// completeGraph has every node depending on every other node
+func completeGraph(size int) *Graph {
+	adjacencyList := make(AdjacencyList)
+	for i := range size {
+		node := fmt.Sprintf("n%02d", i)
+		adjacencyList.AddNode(node)
+		for j := range size {
+			if i != j {
+				adjacencyList.AddEdge(node, fmt.Sprintf("n%02d", j))
+			}
+		}
+	}
+	return NewGraph(adjacencyList)
+}
+
+func TestCycles(t *testing.T) {
+	graph := completeGraph(5)
+	all, complete, err := Cycles(graph, CycleOptions{})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	// 10 cycles of 2 nodes, 20 of 3 nodes, 30 of 4 nodes, and 24 of 5 nodes
+	assert.Len(t, all, 84)
+	assert.True(t, slices.IsSortedFunc(all, slices.Compare))
+	assert.Equal(t, []string{"n00", "n01"}, all[0])
+
+	short, complete, err := Cycles(graph, CycleOptions{MaxLength: 3})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	expected := slices.DeleteFunc(slices.Clone(all), func(cycle []string) bool { return len(cycle) > 3 })
+	assert.Equal(t, expected, short)
+
+	through, complete, err := Cycles(graph, CycleOptions{Through: []string{"n03", "n01"}})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	expected = slices.DeleteFunc(slices.Clone(all), func(cycle []string) bool {
+		return !slices.Contains(cycle, "n01") && !slices.Contains(cycle, "n03")
+	})
+	assert.Equal(t, expected, through)
+
+	through, _, err = Cycles(graph, CycleOptions{Through: []string{"n0*"}, MaxLength: 2})
+	assert.NoError(t, err)
+	assert.Len(t, through, 10)
+
+	limited, complete, err := Cycles(graph, CycleOptions{MaxCycles: 5})
+	assert.NoError(t, err)
+	assert.False(t, complete)
+	assert.Len(t, limited, 5)
+
+	_, _, err = Cycles(graph, CycleOptions{Through: []string{"missing/**"}})
+	assert.Error(t, err)
+}
+
+func TestCyclesTimeout(t *testing.T) {
+	// far too many cycles to find them all
+	start := time.Now()
+	cycles, complete, err := Cycles(completeGraph(20), CycleOptions{Timeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+	assert.False(t, complete)
+	assert.NotEmpty(t, cycles)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCyclesLongChain(t *testing.T) {
+	adjacencyList := make(AdjacencyList)
+	size := 100000
+	for i := range size {
+		adjacencyList[fmt.Sprintf("node-%06d", i)] = []string{fmt.Sprintf("node-%06d", (i+1)%size)}
+	}
+	// a shortcut making a second, shorter cycle
+	adjacencyList["node-000010"] = append(adjacencyList["node-000010"], "node-000000")
+	graph := NewGraph(adjacencyList)
+
+	cycles, complete, err := Cycles(graph, CycleOptions{})
+	assert.NoError(t, err)
+	assert.True(t, complete)
+	assert.Len(t, cycles, 2)
+	assert.Len(t, cycles[0], 11)
+	assert.Len(t, cycles[1], size)
+
+	cycles, _, err = Cycles(graph, CycleOptions{MaxLength: 100, Through: []string{"node-000005"}})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cycles))
+}
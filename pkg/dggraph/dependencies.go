@@ -0,0 +1,91 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"slices"
+)
+
+// DependencyOptions controls which nodes are part of the dependencies (or dependents) of targets
+type DependencyOptions struct {
+	// include transitive dependencies rather than only the direct ones
+	Transitive bool
+	// include the targets themselves (only the ones that are keys of the adjacency list)
+	Reflexive bool
+	// maximum depth of transitive dependencies; 0 for no limit
+	Depth int
+}
+
+/*
+Dependencies gets sorted dependencies of given targets (which may be patterns) in the graph;
+the graph reversed with Reversed can be passed to get dependents instead.
+*/
+func Dependencies(graph *Graph, targets []string, options DependencyOptions) ([]string, error) {
+	targets, err := ExpandPatterns(graph.Nodes(), targets)
+	if err != nil {
+		return nil, err
+	}
+	ids := graph.ToIds(targets)
+
+	var deps []string
+	if options.Transitive {
+		deps = graph.ToNames(graph.Reachable(ids, options.Depth))
+	} else {
+		deps = getDepsDirect(graph, ids)
+	}
+
+	if options.Reflexive {
+		reflexiveTargets := getReflexiveTargets(graph, ids)
+		deps = append(deps, reflexiveTargets...)
+	}
+	slices.Sort(deps)
+	deps = slices.Compact(deps)
+	return deps, nil
+}
+
+// Dependents gets sorted dependents of given targets (which may be patterns) in the graph
+func Dependents(graph *Graph, targets []string, options DependencyOptions) ([]string, error) {
+	return Dependencies(graph.Reversed(), targets, options)
+}
+
+// only the targets that are keys of the adjacency list are part of the reflexive closure
+func getReflexiveTargets(graph *Graph, targets []int) []string {
+	var candidates []string
+	for _, target := range targets {
+		if graph.IsDeclared(target) {
+			candidates = append(candidates, graph.Name(target))
+		}
+	}
+	return candidates
+}
+
+func getDepsDirect(graph *Graph, targets []int) []string {
+	deps := []string{}
+	for _, target := range targets {
+		for _, dep := range graph.Dependencies(target) {
+			deps = append(deps, graph.Name(dep))
+		}
+	}
+	return deps
+}
+
+/*
+ReverseAdjacencyList reverses adjacency lists with dependents of every node sorted:
+{"foo": ["bar", "baz"]} ->
+{"bar": ["foo"], "baz": ["foo"]
+}
+*/
+func ReverseAdjacencyList(adjacencyList AdjacencyList) AdjacencyList {
+	reversed := make(AdjacencyList)
+	for node, dependencies := range adjacencyList {
+		for _, dep := range dependencies {
+			reversed[dep] = append(reversed[dep], node)
+		}
+	}
+	// sorting once all dependents are collected rather than on every insertion
+	for _, dependents := range reversed {
+		slices.Sort(dependents)
+	}
+	return reversed
+}
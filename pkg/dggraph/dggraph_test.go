@@ -0,0 +1,104 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// graph used by the tests of queries: two applications sharing libraries with a cycle between two of them
+var testGraph = NewGraph(AdjacencyList{
+	"app/cli.py":  {"lib/log.py"},
+	"app/main.py": {"lib/db.py", "lib/log.py"},
+	"lib/db.py":   {"lib/log.py", "lib/orm.py"},
+	"lib/orm.py":  {"lib/db.py"},
+	"lib/log.py":  {},
+})
+
+func TestDependencies(t *testing.T) {
+	deps, err := Dependencies(testGraph, []string{"app/main.py"}, DependencyOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/db.py", "lib/log.py"}, deps)
+
+	deps, err = Dependencies(testGraph, []string{"app/*"}, DependencyOptions{Transitive: true, Reflexive: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/cli.py", "app/main.py", "lib/db.py", "lib/log.py", "lib/orm.py"}, deps)
+
+	rdeps, err := Dependents(testGraph, []string{"lib/db.py"}, DependencyOptions{Transitive: true, Depth: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/main.py", "lib/orm.py"}, rdeps)
+
+	_, err = Dependencies(testGraph, []string{"tests/**"}, DependencyOptions{})
+	assert.Error(t, err)
+}
+
+func TestQueries(t *testing.T) {
+	assert.Equal(t, []string{"app/cli.py", "app/main.py"}, Roots(testGraph))
+	assert.Equal(t, []string{"lib/log.py"}, Leaves(testGraph))
+	assert.Equal(t, [][]string{{"app/cli.py", "app/main.py", "lib/db.py", "lib/log.py", "lib/orm.py"}}, ConnectedComponents(testGraph))
+	cycles, _, err := Cycles(testGraph, CycleOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"lib/db.py", "lib/orm.py"}}, cycles)
+
+	paths, err := Paths(testGraph, []string{"app/main.py"}, []string{"lib/log.py"}, 0)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, [][]string{
+		{"app/main.py", "lib/db.py", "lib/log.py"},
+		{"app/main.py", "lib/log.py"},
+	}, paths)
+
+	subgraph, err := Subgraph(testGraph, []string{"lib/orm.py"})
+	assert.NoError(t, err)
+	assert.Equal(t, AdjacencyList{
+		"lib/db.py":  {"lib/log.py", "lib/orm.py"},
+		"lib/orm.py": {"lib/db.py"},
+		"lib/log.py": {},
+	}, subgraph)
+
+	// lib/log.py is a transitive dependency through lib/db.py
+	assert.Equal(t, []string{"lib/db.py"}, TransitiveReduction(testGraph)["app/main.py"])
+	assert.Equal(t, map[string]int{
+		"app/cli.py": 1, "app/main.py": 2, "lib/db.py": 2, "lib/orm.py": 1, "lib/log.py": 0,
+	}, DependencyCounts(testGraph))
+	assert.Equal(t, 3, TransitiveDependencyCounts(testGraph.Reversed())["lib/db.py"])
+
+	nodes, err := Query(testGraph, "rdeps(**, $lib) - $lib", map[string][]string{"lib": {"lib/orm.py"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"app/main.py", "lib/db.py"}, nodes)
+}
+
+func TestReverseAdjacencyList(t *testing.T) {
+	dg := AdjacencyList{"foo": {"bar", "baz"}, "spam": {"eggs", "bar"}}
+	rdg := ReverseAdjacencyList(dg)
+	expected := AdjacencyList{"bar": {"foo", "spam"}, "baz": {"foo"}, "eggs": {"spam"}}
+	assert.True(t, reflect.DeepEqual(rdg, expected))
+}
+
+func TestLoad(t *testing.T) {
+	directory := t.TempDir()
+	filePath := filepath.Join(directory, "dg.csv")
+	os.WriteFile(filePath, []byte("source,target\nfoo.py,bar.py\n"), 0644)
+
+	graph, attributes, err := Load(filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Nil(t, attributes)
+	assert.Equal(t, AdjacencyList{"foo.py": {"bar.py"}}, graph.AdjacencyList())
+
+	// the index of a graph can be parsed regardless of the format
+	indexed, _, err := Parse(EncodeIndex(graph, IndexHeader{Format: FormatCsv}), FormatJson)
+	assert.NoError(t, err)
+	assert.Equal(t, graph, indexed)
+
+	_, _, err = Parse([]byte("{}"), "yaml")
+	assert.Error(t, err)
+	_, _, err = Load(filepath.Join(directory, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"cmp"
+	"slices"
+)
+
+// DiffOptions limits the changes reported by Diff
+type DiffOptions struct {
+	// maximum number of nodes whose numbers of transitive dependencies changed to report; 0 for all
+	Top int
+	// limits of the search of new cycles
+	Cycles CycleOptions
+}
+
+// DependencyCountChange is a change of the number of transitive dependencies of a node
+type DependencyCountChange struct {
+	Node string `json:"node"`
+	Old  int    `json:"old"`
+	New  int    `json:"new"`
+}
+
+// ComponentChange is a change of connected components: old components merged into one or an old component split
+type ComponentChange struct {
+	Old [][]string `json:"old"`
+	New [][]string `json:"new"`
+}
+
+// GraphDiff lists changes between two snapshots of a dependency graph
+type GraphDiff struct {
+	AddedNodes   []string `json:"added_nodes"`
+	RemovedNodes []string `json:"removed_nodes"`
+	AddedEdges   []Edge   `json:"added_edges"`
+	RemovedEdges []Edge   `json:"removed_edges"`
+	// nodes of both graphs whose numbers of transitive dependencies changed the most
+	DependencyCountChanges []DependencyCountChange `json:"dependency_count_changes"`
+	// cycles of the new graph that are not in the old graph
+	NewCycles [][]string `json:"new_cycles"`
+	// whether all new cycles were found (see CycleOptions)
+	CyclesComplete   bool              `json:"cycles_complete"`
+	MergedComponents []ComponentChange `json:"merged_components"`
+	SplitComponents  []ComponentChange `json:"split_components"`
+}
+
+/*
+Diff compares an old and a new snapshot of a dependency graph: nodes and edges added and removed,
+nodes of both graphs whose numbers of transitive dependencies changed the most (sorted by the absolute change,
+the largest first), cycles introduced, and connected components merged or split (considering the nodes of both
+graphs). A new cycle goes through an added edge, so only cycles through the nodes of added edges are searched.
+*/
+func Diff(oldGraph *Graph, newGraph *Graph, options DiffOptions) (GraphDiff, error) {
+	diff := GraphDiff{
+		AddedNodes:     missingNodes(newGraph, oldGraph),
+		RemovedNodes:   missingNodes(oldGraph, newGraph),
+		AddedEdges:     missingEdges(newGraph, oldGraph),
+		RemovedEdges:   missingEdges(oldGraph, newGraph),
+		CyclesComplete: true,
+		NewCycles:      [][]string{},
+	}
+	oldComponents, newComponents := ConnectedComponents(oldGraph), ConnectedComponents(newGraph)
+	diff.MergedComponents = componentChanges(newComponents, oldComponents, false)
+	diff.SplitComponents = componentChanges(oldComponents, newComponents, true)
+
+	oldCounts := TransitiveDependencyCounts(oldGraph)
+	diff.DependencyCountChanges = []DependencyCountChange{}
+	for node, count := range TransitiveDependencyCounts(newGraph) {
+		if oldCount, exists := oldCounts[node]; exists && oldCount != count {
+			diff.DependencyCountChanges = append(diff.DependencyCountChanges, DependencyCountChange{Node: node, Old: oldCount, New: count})
+		}
+	}
+	slices.SortFunc(diff.DependencyCountChanges, func(a DependencyCountChange, b DependencyCountChange) int {
+		if order := cmp.Compare(absolute(b.New-b.Old), absolute(a.New-a.Old)); order != 0 {
+			return order
+		}
+		return cmp.Compare(a.Node, b.Node)
+	})
+	if options.Top > 0 && len(diff.DependencyCountChanges) > options.Top {
+		diff.DependencyCountChanges = diff.DependencyCountChanges[:options.Top]
+	}
+
+	if len(diff.AddedEdges) == 0 {
+		return diff, nil
+	}
+	added := make(map[Edge]bool, len(diff.AddedEdges))
+	through := []string{}
+	for _, edge := range diff.AddedEdges {
+		added[edge] = true
+		// nodes of the graph are searched through by their exact names even if they look like patterns
+		through = append(through, edge.From)
+	}
+	cycleOptions := options.Cycles
+	cycleOptions.Through = slices.Compact(through)
+	cycles, complete, err := Cycles(newGraph, cycleOptions)
+	if err != nil {
+		return GraphDiff{}, err
+	}
+	for _, cycle := range cycles {
+		for i, node := range cycle {
+			if added[Edge{From: node, To: cycle[(i+1)%len(cycle)]}] {
+				diff.NewCycles = append(diff.NewCycles, cycle)
+				break
+			}
+		}
+	}
+	diff.CyclesComplete = complete
+	return diff, nil
+}
+
+func absolute(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+// missingNodes lists the nodes of the graph that are not in the other graph
+func missingNodes(graph *Graph, other *Graph) []string {
+	return slices.DeleteFunc(slices.Clone(graph.Nodes()), func(node string) bool {
+		_, exists := other.Lookup(node)
+		return exists
+	})
+}
+
+// missingEdges lists the edges of the graph that are not in the other graph sorted (see FeedbackArcSet)
+func missingEdges(graph *Graph, other *Graph) []Edge {
+	edges := []Edge{}
+	for node := range graph.Size() {
+		name := graph.Name(node)
+		otherNode, exists := other.Lookup(name)
+		for _, dep := range graph.Dependencies(node) {
+			depName := graph.Name(dep)
+			if exists {
+				if otherDep, found := other.Lookup(depName); found && slices.Contains(other.Dependencies(otherNode), otherDep) {
+					continue
+				}
+			}
+			edges = append(edges, Edge{From: name, To: depName})
+		}
+	}
+	slices.SortFunc(edges, compareEdges)
+	return edges
+}
+
+/*
+Find components matching multiple other components: new components merged from old components or, if split is set,
+old components split into new components; only the nodes of both graphs are used to match components.
+*/
+func componentChanges(from [][]string, to [][]string, split bool) []ComponentChange {
+	componentOf := make(map[string]int)
+	for i, component := range to {
+		for _, node := range component {
+			componentOf[node] = i
+		}
+	}
+	changes := []ComponentChange{}
+	for _, component := range from {
+		matched := []int{}
+		for _, node := range component {
+			if i, exists := componentOf[node]; exists && !slices.Contains(matched, i) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) < 2 {
+			continue
+		}
+		slices.Sort(matched)
+		others := make([][]string, len(matched))
+		for i, index := range matched {
+			others[i] = to[index]
+		}
+		if split {
+			changes = append(changes, ComponentChange{Old: [][]string{component}, New: others})
+		} else {
+			changes = append(changes, ComponentChange{Old: others, New: [][]string{component}})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiff(t *testing.T) {
+	oldGraph := NewGraph(AdjacencyList{
+		"app.py":   {"db.py"},
+		"db.py":    {"log.py"},
+		"cli.py":   {"util.py"},
+		"tools.py": {"legacy.py", "fmt.py"},
+	})
+	newGraph := NewGraph(AdjacencyList{
+		"app.py":   {"db.py", "cli.py"},
+		"db.py":    {"log.py"},
+		"log.py":   {"app.py"},
+		"cli.py":   {"util.py"},
+		"tools.py": {},
+		"fmt.py":   {},
+		"new.py":   {},
+	})
+	diff, err := Diff(oldGraph, newGraph, DiffOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"new.py"}, diff.AddedNodes)
+	assert.Equal(t, []string{"legacy.py"}, diff.RemovedNodes)
+	assert.Equal(t, []Edge{{From: "app.py", To: "cli.py"}, {From: "log.py", To: "app.py"}}, diff.AddedEdges)
+	assert.Equal(t, []Edge{{From: "tools.py", To: "fmt.py"}, {From: "tools.py", To: "legacy.py"}}, diff.RemovedEdges)
+	assert.Equal(t, []DependencyCountChange{
+		{Node: "log.py", Old: 0, New: 5},
+		{Node: "db.py", Old: 1, New: 5},
+		{Node: "app.py", Old: 2, New: 5},
+		{Node: "tools.py", Old: 2, New: 0},
+	}, diff.DependencyCountChanges)
+	assert.Equal(t, [][]string{{"app.py", "db.py", "log.py"}}, diff.NewCycles)
+	assert.True(t, diff.CyclesComplete)
+	assert.Equal(t, []ComponentChange{{
+		Old: [][]string{{"app.py", "db.py", "log.py"}, {"cli.py", "util.py"}},
+		New: [][]string{{"app.py", "cli.py", "db.py", "log.py", "util.py"}},
+	}}, diff.MergedComponents)
+	assert.Equal(t, []ComponentChange{{
+		Old: [][]string{{"fmt.py", "legacy.py", "tools.py"}},
+		New: [][]string{{"fmt.py"}, {"tools.py"}},
+	}}, diff.SplitComponents)
+
+	diff, err = Diff(oldGraph, newGraph, DiffOptions{Top: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []DependencyCountChange{{Node: "log.py", Old: 0, New: 5}}, diff.DependencyCountChanges)
+
+	// cycles that existed before are not new
+	diff, err = Diff(newGraph, newGraph, DiffOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{}, diff.NewCycles)
+	assert.Equal(t, []Edge{}, diff.AddedEdges)
+	assert.Equal(t, []ComponentChange{}, diff.MergedComponents)
+}
@@ -0,0 +1,22 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+
+/*
+Package dggraph provides the dependency graph queries of dg-query as a library operating on an in-memory graph.
+
+A Graph is created from an adjacency list (a mapping of every node to its direct dependencies) with NewGraph,
+or parsed from the contents of a dependency graph file in any of the supported Formats with Parse (or Load
+for a file path). The graph is never modified once it's created, so it can be shared by concurrent queries:
+
+	graph := dggraph.NewGraph(dggraph.AdjacencyList{
+		"app.py": {"db.py", "log.py"},
+		"db.py":  {"log.py"},
+	})
+	deps, err := dggraph.Dependencies(graph, []string{"app.py"}, dggraph.DependencyOptions{Transitive: true})
+	nodes, err := dggraph.Query(graph, "rdeps(**, log.py) - roots(**)", nil)
+
+Targets of the queries can be target patterns such as `src/billing/**`, `//lib/...`, or `re:.*_test\.py`
+(see IsPattern).
+*/
+package dggraph
@@ -0,0 +1,397 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+/*
+Load JSON Lines where every line is either a node record with its dependencies
+or a single edge record, e.g.:
+{"node": "foo.py", "dependencies": ["bar.py"]}
+{"source": "bar.py", "target": "baz.py"}
+*/
+func loadJsonLinesFile(data []byte) (AdjacencyList, error) {
+	adjacencyList := make(AdjacencyList)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// lines with nodes having lots of dependencies may exceed the default buffer size
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record struct {
+			Node         string   `json:"node"`
+			Dependencies []string `json:"dependencies"`
+			Source       string   `json:"source"`
+			Target       string   `json:"target"`
+		}
+		if err := json.Unmarshal(line, &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		switch {
+		case record.Node != "":
+			adjacencyList.AddNode(record.Node)
+			for _, dep := range record.Dependencies {
+				adjacencyList.appendEdge(record.Node, dep)
+			}
+		case record.Source != "" && record.Target != "":
+			adjacencyList.appendEdge(record.Source, record.Target)
+		default:
+			return nil, fmt.Errorf("line %d: expected either a \"node\" or a \"source\" and \"target\" fields", lineNumber)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	adjacencyList.dropDuplicateEdges()
+	return adjacencyList, nil
+}
+
+// names of the first column that make the first row of an edge list a header
+var edgeListHeaders = []string{"source", "src", "from", "node", "dependent"}
+
+func loadCsvFile(data []byte) (AdjacencyList, error) {
+	return loadEdgeList(data, ',')
+}
+
+func loadTsvFile(data []byte) (AdjacencyList, error) {
+	return loadEdgeList(data, '\t')
+}
+
+/*
+Load an edge list with one "node,dependency" pair per row; rows with a single
+column declare nodes without dependencies. The header row is optional.
+*/
+func loadEdgeList(data []byte, separator rune) (AdjacencyList, error) {
+	adjacencyList := make(AdjacencyList)
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.Comma = separator
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	isFirstRecord := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if isFirstRecord {
+			isFirstRecord = false
+			if slices.Contains(edgeListHeaders, strings.ToLower(strings.TrimSpace(record[0]))) {
+				continue
+			}
+		}
+		node := strings.TrimSpace(record[0])
+		if node == "" {
+			continue
+		}
+		if len(record) == 1 || strings.TrimSpace(record[1]) == "" {
+			adjacencyList.AddNode(node)
+			continue
+		}
+		adjacencyList.appendEdge(node, strings.TrimSpace(record[1]))
+	}
+	adjacencyList.dropDuplicateEdges()
+	return adjacencyList, nil
+}
+
+type graphmlDocument struct {
+	Graphs []struct {
+		Nodes []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Target string `xml:"target,attr"`
+		} `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// loadGraphmlFile loads nodes and edges of every graph declared in a GraphML document
+func loadGraphmlFile(data []byte) (AdjacencyList, error) {
+	var document graphmlDocument
+	if err := xml.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	adjacencyList := make(AdjacencyList)
+	for _, graph := range document.Graphs {
+		for _, node := range graph.Nodes {
+			adjacencyList.AddNode(node.ID)
+		}
+		for _, edge := range graph.Edges {
+			adjacencyList.appendEdge(edge.Source, edge.Target)
+		}
+	}
+	adjacencyList.dropDuplicateEdges()
+	return adjacencyList, nil
+}
+
+type dotToken struct {
+	value string
+	// quoted identifiers are never treated as keywords or punctuation
+	quoted bool
+}
+
+func (token dotToken) is(value string) bool {
+	return !token.quoted && strings.EqualFold(token.value, value)
+}
+
+// tokenizeDot splits a DOT document into identifiers and punctuation skipping comments
+func tokenizeDot(data []byte) ([]dotToken, error) {
+	text := string(data)
+	tokens := []dotToken{}
+	isIdentifierChar := func(c byte) bool {
+		return c == '_' || c == '.' || c >= 0x80 ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case strings.HasPrefix(text[i:], "//") || (c == '#' && (i == 0 || text[i-1] == '\n')):
+			for i < len(text) && text[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(text[i:], "/*"):
+			end := strings.Index(text[i+2:], "*/")
+			if end == -1 {
+				return nil, errors.New("unterminated comment")
+			}
+			i += end + 4
+		case strings.HasPrefix(text[i:], "->") || strings.HasPrefix(text[i:], "--"):
+			tokens = append(tokens, dotToken{value: text[i : i+2]})
+			i += 2
+		case strings.ContainsRune("{}[];,=:", rune(c)):
+			tokens = append(tokens, dotToken{value: string(c)})
+			i++
+		case c == '"':
+			var value strings.Builder
+			i++
+			for ; i < len(text) && text[i] != '"'; i++ {
+				// only the escaped quote is unescaped, other escape sequences are kept as is
+				if text[i] == '\\' && i+1 < len(text) && text[i+1] == '"' {
+					i++
+				}
+				value.WriteByte(text[i])
+			}
+			if i >= len(text) {
+				return nil, errors.New("unterminated quoted string")
+			}
+			i++
+			tokens = append(tokens, dotToken{value: value.String(), quoted: true})
+		case c == '<':
+			// HTML strings may contain nested angle brackets
+			depth, start := 0, i
+			for ; i < len(text); i++ {
+				if text[i] == '<' {
+					depth++
+				} else if text[i] == '>' {
+					depth--
+					if depth == 0 {
+						break
+					}
+				}
+			}
+			if i >= len(text) {
+				return nil, errors.New("unterminated HTML string")
+			}
+			i++
+			tokens = append(tokens, dotToken{value: text[start+1 : i-1], quoted: true})
+		case isIdentifierChar(c) || c == '-':
+			start := i
+			for i++; i < len(text) && isIdentifierChar(text[i]); i++ {
+			}
+			tokens = append(tokens, dotToken{value: text[start:i]})
+		default:
+			return nil, fmt.Errorf("unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+type dotParser struct {
+	tokens        []dotToken
+	position      int
+	adjacencyList AdjacencyList
+}
+
+func (parser *dotParser) peek() (dotToken, bool) {
+	if parser.position >= len(parser.tokens) {
+		return dotToken{}, false
+	}
+	return parser.tokens[parser.position], true
+}
+
+func (parser *dotParser) next() (dotToken, error) {
+	token, ok := parser.peek()
+	if !ok {
+		return dotToken{}, io.ErrUnexpectedEOF
+	}
+	parser.position++
+	return token, nil
+}
+
+// accept consumes the next token if it's the given punctuation or keyword
+func (parser *dotParser) accept(value string) bool {
+	if token, ok := parser.peek(); ok && token.is(value) {
+		parser.position++
+		return true
+	}
+	return false
+}
+
+func (parser *dotParser) expect(value string) error {
+	token, err := parser.next()
+	if err != nil {
+		return err
+	}
+	if !token.is(value) {
+		return fmt.Errorf("expected %q, got %q", value, token.value)
+	}
+	return nil
+}
+
+// skipAttributes skips any number of attribute lists such as `[color=red]`
+func (parser *dotParser) skipAttributes() error {
+	for parser.accept("[") {
+		for !parser.accept("]") {
+			if _, err := parser.next(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseStatements parses statements until the closing brace returning all nodes mentioned
+func (parser *dotParser) parseStatements() ([]string, error) {
+	nodes := []string{}
+	for !parser.accept("}") {
+		statementNodes, err := parser.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, statementNodes...)
+		for parser.accept(";") || parser.accept(",") {
+		}
+	}
+	return nodes, nil
+}
+
+// parseOperand parses either a node ID (with an optional port) or a subgraph
+func (parser *dotParser) parseOperand() ([]string, error) {
+	token, err := parser.next()
+	if err != nil {
+		return nil, err
+	}
+	if token.is("subgraph") {
+		if next, ok := parser.peek(); ok && !next.is("{") {
+			parser.position++
+		}
+		token, err = parser.next()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if token.is("{") {
+		return parser.parseStatements()
+	}
+	if !token.quoted && strings.ContainsAny(token.value, "{}[];,=:") {
+		return nil, fmt.Errorf("expected node ID, got %q", token.value)
+	}
+	// ports and compass points are not part of the node ID
+	for parser.accept(":") {
+		if _, err := parser.next(); err != nil {
+			return nil, err
+		}
+	}
+	parser.adjacencyList.AddNode(token.value)
+	return []string{token.value}, nil
+}
+
+func (parser *dotParser) parseStatement() ([]string, error) {
+	token, ok := parser.peek()
+	if !ok {
+		return nil, io.ErrUnexpectedEOF
+	}
+	// default attributes for graph, nodes, and edges
+	if token.is("graph") || token.is("node") || token.is("edge") {
+		parser.position++
+		return nil, parser.skipAttributes()
+	}
+	// graph attribute assignment such as `rankdir=LR`
+	if parser.position+1 < len(parser.tokens) && parser.tokens[parser.position+1].is("=") {
+		parser.position += 3
+		return nil, nil
+	}
+
+	left, err := parser.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	nodes := left
+	for parser.accept("->") || parser.accept("--") {
+		right, err := parser.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range left {
+			for _, dependency := range right {
+				parser.adjacencyList.appendEdge(node, dependency)
+			}
+		}
+		nodes = append(nodes, right...)
+		left = right
+	}
+	return nodes, parser.skipAttributes()
+}
+
+/*
+Load a Graphviz DOT document; every `a -> b` edge is treated as "a depends on b".
+Node and edge attributes are ignored.
+*/
+func loadDotFile(data []byte) (AdjacencyList, error) {
+	tokens, err := tokenizeDot(data)
+	if err != nil {
+		return nil, err
+	}
+	parser := &dotParser{tokens: tokens, adjacencyList: make(AdjacencyList)}
+	parser.accept("strict")
+	if !parser.accept("digraph") && !parser.accept("graph") {
+		return nil, errors.New("expected \"digraph\" or \"graph\" keyword")
+	}
+	if token, ok := parser.peek(); ok && !token.is("{") {
+		parser.position++
+	}
+	if err := parser.expect("{"); err != nil {
+		return nil, err
+	}
+	if _, err := parser.parseStatements(); err != nil {
+		return nil, err
+	}
+	parser.adjacencyList.dropDuplicateEdges()
+	return parser.adjacencyList, nil
+}
@@ -0,0 +1,323 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"slices"
+)
+
+// AdjacencyList maps every node to its direct dependencies
+type AdjacencyList map[string][]string
+
+// Nodes lists all nodes (including the ones present only as dependencies) sorted
+func (adjacencyList AdjacencyList) Nodes() []string {
+	nodes := make(map[string]bool)
+	for node, deps := range adjacencyList {
+		nodes[node] = true
+		for _, dep := range deps {
+			nodes[dep] = true
+		}
+	}
+	return sortedKeys(nodes)
+}
+
+// AddNode makes sure the node is present in the adjacency list as a key
+func (adjacencyList AdjacencyList) AddNode(node string) {
+	if _, exists := adjacencyList[node]; !exists {
+		adjacencyList[node] = []string{}
+	}
+}
+
+// AddEdge adds a dependency of a node skipping the duplicate edges
+func (adjacencyList AdjacencyList) AddEdge(node string, dependency string) {
+	if !slices.Contains(adjacencyList[node], dependency) {
+		adjacencyList[node] = append(adjacencyList[node], dependency)
+	}
+}
+
+/*
+appendEdge adds a dependency of a node keeping the duplicate edges which loaders drop once the adjacency list
+is complete (see dropDuplicateEdges) as skipping them on every edge is quadratic in the number of dependencies.
+*/
+func (adjacencyList AdjacencyList) appendEdge(node string, dependency string) {
+	adjacencyList[node] = append(adjacencyList[node], dependency)
+}
+
+// dropDuplicateEdges removes the duplicate dependencies of every node in a single pass keeping the first ones
+func (adjacencyList AdjacencyList) dropDuplicateEdges() {
+	seen := make(map[string]bool)
+	for node, deps := range adjacencyList {
+		if len(deps) < 2 {
+			continue
+		}
+		clear(seen)
+		adjacencyList[node] = slices.DeleteFunc(deps, func(dep string) bool {
+			duplicate := seen[dep]
+			seen[dep] = true
+			return duplicate
+		})
+	}
+}
+
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+/*
+Graph is a dependency graph indexed once it's loaded so that queries do not need to work
+on the adjacency list directly: node names are interned into dense integer IDs assigned
+in the sorted order of names (so iterating IDs yields nodes sorted), and both dependencies
+and dependents of every node are stored as compressed sparse rows of IDs, i.e. dependencies
+of node `id` are `depTargets[depOffsets[id]:depOffsets[id+1]]`.
+*/
+type Graph struct {
+	names []string
+	ids   map[string]int
+
+	depOffsets  []int
+	depTargets  []int
+	rdepOffsets []int
+	rdepTargets []int
+
+	// nodes that are keys of the adjacency list rather than only dependencies of other nodes
+	declared []bool
+}
+
+/*
+NewGraph indexes an adjacency list; nodes present only as dependencies of other nodes are part of the graph,
+dependencies keep the order of the adjacency list with duplicates dropped, and dependents are sorted.
+*/
+func NewGraph(adjacencyList AdjacencyList) *Graph {
+	names := adjacencyList.Nodes()
+	graph := &Graph{
+		names:      names,
+		ids:        make(map[string]int, len(names)),
+		depOffsets: make([]int, 0, len(names)+1),
+		declared:   make([]bool, len(names)),
+	}
+	for id, name := range names {
+		graph.ids[name] = id
+	}
+
+	// the node that last added a dependency to skip duplicate dependencies
+	addedBy := make([]int, len(names))
+	for i := range addedBy {
+		addedBy[i] = -1
+	}
+	graph.depOffsets = append(graph.depOffsets, 0)
+	for id, name := range names {
+		deps, declared := adjacencyList[name]
+		graph.declared[id] = declared
+		for _, dep := range deps {
+			depId := graph.ids[dep]
+			if addedBy[depId] != id {
+				addedBy[depId] = id
+				graph.depTargets = append(graph.depTargets, depId)
+			}
+		}
+		graph.depOffsets = append(graph.depOffsets, len(graph.depTargets))
+	}
+
+	graph.rdepOffsets, graph.rdepTargets = reverseRows(graph.depOffsets, graph.depTargets)
+	return graph
+}
+
+// reverseRows transposes compressed sparse rows; rows of the result are sorted as rows are visited in order
+func reverseRows(offsets []int, targets []int) ([]int, []int) {
+	reversedOffsets := make([]int, len(offsets))
+	for _, target := range targets {
+		reversedOffsets[target+1]++
+	}
+	for i := 1; i < len(reversedOffsets); i++ {
+		reversedOffsets[i] += reversedOffsets[i-1]
+	}
+	reversedTargets := make([]int, len(targets))
+	next := slices.Clone(reversedOffsets[:len(reversedOffsets)-1])
+	for source := 0; source+1 < len(offsets); source++ {
+		for _, target := range targets[offsets[source]:offsets[source+1]] {
+			reversedTargets[next[target]] = source
+			next[target]++
+		}
+	}
+	return reversedOffsets, reversedTargets
+}
+
+// Size returns the number of nodes in the graph
+func (graph *Graph) Size() int {
+	return len(graph.names)
+}
+
+// Nodes returns names of all nodes sorted; the slice is shared and must not be modified
+func (graph *Graph) Nodes() []string {
+	return graph.names
+}
+
+// Name returns the name of the node with given ID
+func (graph *Graph) Name(id int) string {
+	return graph.names[id]
+}
+
+// Lookup returns the ID of the node with given name if it's in the graph
+func (graph *Graph) Lookup(name string) (int, bool) {
+	id, exists := graph.ids[name]
+	return id, exists
+}
+
+// Dependencies returns IDs of direct dependencies of a node; the slice is shared and must not be modified
+func (graph *Graph) Dependencies(id int) []int {
+	return graph.depTargets[graph.depOffsets[id]:graph.depOffsets[id+1]]
+}
+
+// Dependents returns sorted IDs of direct dependents of a node; the slice is shared and must not be modified
+func (graph *Graph) Dependents(id int) []int {
+	return graph.rdepTargets[graph.rdepOffsets[id]:graph.rdepOffsets[id+1]]
+}
+
+// IsDeclared checks if the node is a key of the adjacency list the graph was created from
+func (graph *Graph) IsDeclared(id int) bool {
+	return graph.declared[id]
+}
+
+/*
+Reversed gets the graph with all edges reversed (i.e. dependents become dependencies) sharing the index
+with this graph; nodes that have dependents in this graph are the declared ones of the reversed graph
+as if it was created from the reversed adjacency list.
+*/
+func (graph *Graph) Reversed() *Graph {
+	declared := make([]bool, graph.Size())
+	for id := range declared {
+		declared[id] = len(graph.Dependents(id)) > 0
+	}
+	return &Graph{
+		names:       graph.names,
+		ids:         graph.ids,
+		depOffsets:  graph.rdepOffsets,
+		depTargets:  graph.rdepTargets,
+		rdepOffsets: graph.depOffsets,
+		rdepTargets: graph.depTargets,
+		declared:    declared,
+	}
+}
+
+// ToIds gets IDs of given nodes skipping the ones that are not in the graph
+func (graph *Graph) ToIds(names []string) []int {
+	ids := make([]int, 0, len(names))
+	for _, name := range names {
+		if id, exists := graph.ids[name]; exists {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ToNames gets names of nodes with given IDs
+func (graph *Graph) ToNames(ids []int) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = graph.names[id]
+	}
+	return names
+}
+
+// AdjacencyList converts the graph back into an adjacency list with a key for every declared node
+func (graph *Graph) AdjacencyList() AdjacencyList {
+	adjacencyList := make(AdjacencyList)
+	for id, name := range graph.names {
+		if graph.declared[id] {
+			adjacencyList[name] = graph.ToNames(graph.Dependencies(id))
+		}
+	}
+	return adjacencyList
+}
+
+/*
+Traversal keeps the state of breadth-first searches so that it's allocated once for many searches
+(e.g. when counting transitive dependencies of every node); nodes are marked with the number
+of the search instead of clearing the marks before every search.
+*/
+type Traversal struct {
+	graph   *Graph
+	search  int
+	visited []int
+	reached []int
+	queue   []int
+	// node every node was first visited from in the last search (-1 for the sources)
+	parent []int
+}
+
+// NewTraversal allocates the state of breadth-first searches of the graph
+func (graph *Graph) NewTraversal() *Traversal {
+	return &Traversal{
+		graph:   graph,
+		visited: make([]int, graph.Size()),
+		reached: make([]int, graph.Size()),
+		parent:  make([]int, graph.Size()),
+	}
+}
+
+/*
+Reachable finds the nodes reachable from given nodes through at least one dependency up to given depth
+(0 for no limit) sorted by ID; the given nodes are part of the result only if they can be reached
+from any of them (e.g. being part of a cycle). If `accept` is set, only the dependencies it accepts are followed.
+*/
+func (search *Traversal) Reachable(sources []int, depth int, accept func(id int) bool) []int {
+	search.search++
+	search.queue = search.queue[:0]
+	for _, source := range sources {
+		if search.visited[source] != search.search {
+			search.visited[source] = search.search
+			search.parent[source] = -1
+			search.queue = append(search.queue, source)
+		}
+	}
+
+	result := []int{}
+	// the queue is processed level by level to keep track of the depth
+	for level, start := 1, 0; start < len(search.queue) && (depth == 0 || level <= depth); level++ {
+		end := len(search.queue)
+		for _, node := range search.queue[start:end] {
+			for _, dep := range search.graph.Dependencies(node) {
+				if accept != nil && !accept(dep) {
+					continue
+				}
+				if search.reached[dep] != search.search {
+					search.reached[dep] = search.search
+					result = append(result, dep)
+				}
+				if search.visited[dep] != search.search {
+					search.visited[dep] = search.search
+					search.parent[dep] = node
+					search.queue = append(search.queue, dep)
+				}
+			}
+		}
+		start = end
+	}
+	slices.Sort(result)
+	return result
+}
+
+/*
+Chain gets the chain of dependencies the node was first reached through in the last search, starting from
+the source it was reached from and ending with the node; as the search is breadth-first, it's a shortest chain.
+*/
+func (search *Traversal) Chain(id int) []int {
+	chain := []int{id}
+	for search.parent[id] >= 0 {
+		id = search.parent[id]
+		chain = append(chain, id)
+	}
+	slices.Reverse(chain)
+	return chain
+}
+
+// Reachable finds the nodes reachable from given nodes up to given depth (0 for no limit) sorted by ID
+func (graph *Graph) Reachable(sources []int, depth int) []int {
+	return graph.NewTraversal().Reachable(sources, depth, nil)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewGraph(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"foo.py":  {"spam.py", "bar.py", "spam.py"},
+		"bar.py":  {"spam.py"},
+		"eggs.py": {},
+	})
+	// nodes present only as dependencies are part of the graph and IDs follow the sorted order of nodes
+	assert.Equal(t, []string{"bar.py", "eggs.py", "foo.py", "spam.py"}, graph.Nodes())
+	foo, _ := graph.Lookup("foo.py")
+	spam, _ := graph.Lookup("spam.py")
+	_, exists := graph.Lookup("ham.py")
+	assert.False(t, exists)
+
+	// dependencies keep their order without duplicates while dependents are sorted
+	assert.Equal(t, []string{"spam.py", "bar.py"}, graph.ToNames(graph.Dependencies(foo)))
+	assert.Equal(t, []string{"bar.py", "foo.py"}, graph.ToNames(graph.Dependents(spam)))
+	assert.True(t, graph.IsDeclared(foo))
+	assert.False(t, graph.IsDeclared(spam))
+
+	reversed := graph.Reversed()
+	assert.Equal(t, []string{"bar.py", "foo.py"}, reversed.ToNames(reversed.Dependencies(spam)))
+	assert.True(t, reversed.IsDeclared(spam))
+	assert.False(t, reversed.IsDeclared(foo))
+
+	assert.Equal(t, AdjacencyList{
+		"foo.py":  {"spam.py", "bar.py"},
+		"bar.py":  {"spam.py"},
+		"eggs.py": {},
+	}, graph.AdjacencyList())
+}
+
+func TestReversedNodeDeclaredWithoutDependencies(t *testing.T) {
+	// a node declared without dependencies keeps its dependents when the graph is reversed
+	// (reversing the adjacency list used to replace them with the empty list of the declaration)
+	graph := NewGraph(AdjacencyList{
+		"foo.py": {},
+		"bar.py": {"foo.py"},
+		"baz.py": {"bar.py"},
+	})
+	assert.Equal(t, AdjacencyList{
+		"foo.py": {"bar.py"},
+		"bar.py": {"baz.py"},
+	}, graph.Reversed().AdjacencyList())
+}
+
+func TestDropDuplicateEdges(t *testing.T) {
+	adjacencyList := make(AdjacencyList)
+	for _, dep := range []string{"spam.py", "bar.py", "spam.py", "bar.py", "eggs.py"} {
+		adjacencyList.appendEdge("foo.py", dep)
+	}
+	adjacencyList.AddNode("bar.py")
+	adjacencyList.dropDuplicateEdges()
+	assert.Equal(t, AdjacencyList{"foo.py": {"spam.py", "bar.py", "eggs.py"}, "bar.py": {}}, adjacencyList)
+
+	// loaders drop the duplicate edges
+	adjacencyList, err := loadEdgeList([]byte("foo.py,bar.py\nfoo.py,baz.py\nfoo.py,bar.py\n"), ',')
+	assert.NoError(t, err)
+	assert.Equal(t, AdjacencyList{"foo.py": {"bar.py", "baz.py"}}, adjacencyList)
+}
+
+func TestGraphReachable(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"a": {"b", "c"},
+		"b": {"d"},
+		"c": {"d"},
+		"d": {"e"},
+		"e": {"d"},
+	})
+	ids := func(names ...string) []int {
+		return graph.ToIds(names)
+	}
+	search := graph.NewTraversal()
+
+	assert.Equal(t, ids("b", "c", "d", "e"), search.Reachable(ids("a"), 0, nil))
+	assert.Equal(t, ids("b", "c"), search.Reachable(ids("a"), 1, nil))
+	assert.Equal(t, ids("b", "c", "d"), search.Reachable(ids("a"), 2, nil))
+	// nodes in a cycle can reach themselves
+	assert.Equal(t, ids("d", "e"), search.Reachable(ids("d"), 0, nil))
+	assert.Equal(t, ids("d", "e"), search.Reachable(ids("b", "e"), 0, nil))
+	// only accepted nodes are followed
+	c, _ := graph.Lookup("c")
+	assert.Equal(t, ids("b", "d", "e"), search.Reachable(ids("a"), 0, func(id int) bool { return id != c }))
+	assert.Equal(t, []int{}, search.Reachable(ids("e"), 0, func(id int) bool { return false }))
+}
@@ -0,0 +1,235 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+/*
+The index of a dependency graph is a binary file with the graph already parsed and indexed
+so that loading it is much faster than parsing the dependency graph file. All numbers are
+little-endian unsigned 32-bit integers:
+
+	magic                  "DGQINDEX"
+	version                format version of the index
+	checksum               SHA-256 of the (decompressed) dependency graph file
+	format                 length and bytes of the input format the graph was parsed as
+	nodes, edges           number of nodes and edges
+	names                  length and bytes of all node names concatenated in the order of IDs
+	name offsets           nodes+1 offsets of node names within the concatenated names
+	declared               bitset of nodes that are keys of the adjacency list
+	dependency offsets     nodes+1 offsets into the dependency targets (compressed sparse rows)
+	dependency targets     edges node IDs
+	dependent offsets      nodes+1 offsets into the dependent targets
+	dependent targets      edges node IDs
+*/
+const (
+	indexMagic   = "DGQINDEX"
+	indexVersion = 1
+)
+
+// ErrCorruptIndex is returned when decoding an index that is truncated or not consistent
+var ErrCorruptIndex = errors.New("corrupt index")
+
+// IndexHeader identifies the dependency graph file the index was written for
+type IndexHeader struct {
+	// SHA-256 of the (decompressed) dependency graph file
+	Checksum [sha256.Size]byte
+	// input format the dependency graph file was parsed as
+	Format string
+}
+
+// IsIndex checks if the file contents is an index rather than a dependency graph file
+func IsIndex(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(indexMagic))
+}
+
+// EncodeIndex serializes the graph as an index
+func EncodeIndex(graph *Graph, header IndexHeader) []byte {
+	var output bytes.Buffer
+	writeUint := func(value int) {
+		output.Write(binary.LittleEndian.AppendUint32(nil, uint32(value)))
+	}
+	writeUints := func(values []int) {
+		buffer := make([]byte, 0, 4*len(values))
+		for _, value := range values {
+			buffer = binary.LittleEndian.AppendUint32(buffer, uint32(value))
+		}
+		output.Write(buffer)
+	}
+
+	output.WriteString(indexMagic)
+	writeUint(indexVersion)
+	output.Write(header.Checksum[:])
+	writeUint(len(header.Format))
+	output.WriteString(header.Format)
+	writeUint(graph.Size())
+	writeUint(len(graph.depTargets))
+
+	nameOffsets := make([]int, 0, graph.Size()+1)
+	length := 0
+	for _, name := range graph.names {
+		nameOffsets = append(nameOffsets, length)
+		length += len(name)
+	}
+	nameOffsets = append(nameOffsets, length)
+	writeUint(length)
+	for _, name := range graph.names {
+		output.WriteString(name)
+	}
+	writeUints(nameOffsets)
+
+	declared := make([]byte, (graph.Size()+7)/8)
+	for id, isDeclared := range graph.declared {
+		if isDeclared {
+			declared[id/8] |= 1 << (id % 8)
+		}
+	}
+	output.Write(declared)
+
+	writeUints(graph.depOffsets)
+	writeUints(graph.depTargets)
+	writeUints(graph.rdepOffsets)
+	writeUints(graph.rdepTargets)
+	return output.Bytes()
+}
+
+// indexReader reads an index keeping the first error so that it's checked only once all values are read
+type indexReader struct {
+	data []byte
+	err  error
+}
+
+func (reader *indexReader) bytes(length int) []byte {
+	if reader.err != nil {
+		return nil
+	}
+	if length < 0 || len(reader.data) < length {
+		reader.err = ErrCorruptIndex
+		return nil
+	}
+	value := reader.data[:length]
+	reader.data = reader.data[length:]
+	return value
+}
+
+func (reader *indexReader) uint() int {
+	value := reader.bytes(4)
+	if value == nil {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint32(value))
+}
+
+// offsets reads compressed sparse row offsets checking they're increasing up to the number of values
+func (reader *indexReader) offsets(count int, total int) []int {
+	offsets := reader.uints(count)
+	for i, offset := range offsets {
+		if (i == 0 && offset != 0) || (i > 0 && offset < offsets[i-1]) || offset > total {
+			reader.err = ErrCorruptIndex
+			return nil
+		}
+	}
+	if reader.err == nil && offsets[len(offsets)-1] != total {
+		reader.err = ErrCorruptIndex
+	}
+	return offsets
+}
+
+// uintsBelow reads given number of values checking they're less than the limit
+func (reader *indexReader) uintsBelow(count int, limit int) []int {
+	values := reader.uints(count)
+	for _, value := range values {
+		if value >= limit {
+			reader.err = ErrCorruptIndex
+			return nil
+		}
+	}
+	return values
+}
+
+func (reader *indexReader) uints(count int) []int {
+	data := reader.bytes(4 * count)
+	if data == nil {
+		return nil
+	}
+	values := make([]int, count)
+	for i := range values {
+		values[i] = int(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return values
+}
+
+func (reader *indexReader) header() IndexHeader {
+	var header IndexHeader
+	if string(reader.bytes(len(indexMagic))) != indexMagic {
+		reader.err = ErrCorruptIndex
+		return header
+	}
+	if version := reader.uint(); reader.err == nil && version != indexVersion {
+		reader.err = fmt.Errorf("unsupported index version %d, the index has to be written again", version)
+		return header
+	}
+	copy(header.Checksum[:], reader.bytes(sha256.Size))
+	header.Format = string(reader.bytes(reader.uint()))
+	return header
+}
+
+// DecodeIndexHeader reads only the header of an index to check whether it's up to date
+func DecodeIndexHeader(data []byte) (IndexHeader, error) {
+	reader := &indexReader{data: data}
+	header := reader.header()
+	return header, reader.err
+}
+
+/*
+Deserialize the graph from an index validating it can be traversed safely and that lookups are correct:
+node names must be sorted and unique and the dependents must be the transpose of the dependencies.
+*/
+func DecodeIndex(data []byte) (*Graph, error) {
+	reader := &indexReader{data: data}
+	reader.header()
+	nodesCount := reader.uint()
+	edgesCount := reader.uint()
+	names := string(reader.bytes(reader.uint()))
+	nameOffsets := reader.offsets(nodesCount+1, len(names))
+	declared := reader.bytes((nodesCount + 7) / 8)
+	depOffsets := reader.offsets(nodesCount+1, edgesCount)
+	depTargets := reader.uintsBelow(edgesCount, nodesCount)
+	rdepOffsets := reader.offsets(nodesCount+1, edgesCount)
+	rdepTargets := reader.uintsBelow(edgesCount, nodesCount)
+	if reader.err != nil {
+		return nil, reader.err
+	}
+
+	graph := &Graph{
+		names:       make([]string, nodesCount),
+		ids:         make(map[string]int, nodesCount),
+		depOffsets:  depOffsets,
+		depTargets:  depTargets,
+		rdepOffsets: rdepOffsets,
+		rdepTargets: rdepTargets,
+		declared:    make([]bool, nodesCount),
+	}
+	// node names share the memory of the concatenated names
+	for id := range nodesCount {
+		graph.names[id] = names[nameOffsets[id]:nameOffsets[id+1]]
+		if id > 0 && graph.names[id] <= graph.names[id-1] {
+			return nil, ErrCorruptIndex
+		}
+		graph.ids[graph.names[id]] = id
+		graph.declared[id] = declared[id/8]&(1<<(id%8)) != 0
+	}
+	reversedOffsets, reversedTargets := reverseRows(depOffsets, depTargets)
+	if !slices.Equal(reversedOffsets, rdepOffsets) || !slices.Equal(reversedTargets, rdepTargets) {
+		return nil, ErrCorruptIndex
+	}
+	return graph, nil
+}
@@ -0,0 +1,62 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphIndexRoundTrip(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"foo.py":  {"spam.py", "bar.py"},
+		"bar.py":  {"spam.py"},
+		"eggs.py": {},
+		"ham.py":  {"ham.py"},
+	})
+	header := IndexHeader{Checksum: sha256.Sum256([]byte("{}")), Format: FormatJson}
+	data := EncodeIndex(graph, header)
+	assert.True(t, IsIndex(data))
+
+	decodedHeader, err := DecodeIndexHeader(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, header, decodedHeader)
+	decoded, err := DecodeIndex(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, graph, decoded)
+
+	// truncated or corrupted indexes are never loaded
+	for _, corrupted := range [][]byte{data[:len(data)-1], data[:len(indexMagic)+2], []byte(indexMagic)} {
+		_, err = DecodeIndex(corrupted)
+		assert.Error(t, err)
+	}
+	corrupted := append([]byte{}, data...)
+	corrupted[len(corrupted)-1] = 0xff
+	_, err = DecodeIndex(corrupted)
+	assert.Error(t, err)
+}
+
+func TestGraphIndexInconsistent(t *testing.T) {
+	header := IndexHeader{Format: FormatJson}
+	inconsistent := func(change func(graph *Graph)) []byte {
+		graph := NewGraph(AdjacencyList{"a.py": {"b.py", "c.py"}, "b.py": {"c.py"}})
+		change(graph)
+		return EncodeIndex(graph, header)
+	}
+	cases := map[string][]byte{
+		"unsorted names":   inconsistent(func(graph *Graph) { graph.names[0], graph.names[1] = graph.names[1], graph.names[0] }),
+		"duplicate names":  inconsistent(func(graph *Graph) { graph.names[1] = graph.names[0] }),
+		"wrong dependents": inconsistent(func(graph *Graph) { graph.rdepTargets[0], graph.rdepTargets[1] = 1, 1 }),
+	}
+	for name, data := range cases {
+		_, err := DecodeIndex(data)
+		assert.ErrorIs(t, err, ErrCorruptIndex, name)
+	}
+}
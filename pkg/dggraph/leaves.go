@@ -0,0 +1,16 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+// Leaves returns nodes (aka sinks) that have no dependencies sorted
+func Leaves(graph *Graph) []string {
+	// nodes are sorted by their IDs
+	result := []string{}
+	for id := range graph.Size() {
+		if len(graph.Dependencies(id)) == 0 {
+			result = append(result, graph.Name(id))
+		}
+	}
+	return result
+}
@@ -0,0 +1,193 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Function type to be used for parsing file contents into an adjacency list;
+// node attributes are returned only by the formats that provide them
+type LoaderFunc func(data []byte) (AdjacencyList, NodeAttributes, error)
+
+// NodeAttributes maps nodes to their attributes such as target type or sources
+type NodeAttributes map[string]map[string]any
+
+const (
+	FormatJson      = "json"
+	FormatJsonLines = "jsonl"
+	FormatDot       = "dot"
+	FormatCsv       = "csv"
+	FormatTsv       = "tsv"
+	FormatGraphml   = "graphml"
+	FormatPants     = "pants"
+
+	FormatBazelGraph     = "bazel-graph"
+	FormatBazelProto     = "bazel-proto"
+	FormatBazelJsonProto = "bazel-jsonproto"
+	FormatBazelXml       = "bazel-xml"
+)
+
+// loaders maps every supported input format to a function producing an adjacency list
+var loaders = map[string]LoaderFunc{
+	// a top-level array is the output of `pants peek` saved as JSON (see loadPantsFile)
+	FormatJson:      loadPantsFile,
+	FormatJsonLines: withoutAttributes(loadJsonLinesFile),
+	FormatDot:       withoutAttributes(loadDotFile),
+	FormatCsv:       withoutAttributes(loadCsvFile),
+	FormatTsv:       withoutAttributes(loadTsvFile),
+	FormatGraphml:   withoutAttributes(loadGraphmlFile),
+	FormatPants:     loadPantsFile,
+
+	FormatBazelGraph:     withoutAttributes(loadBazelGraphFile),
+	FormatBazelProto:     loadBazelProtoFile,
+	FormatBazelJsonProto: loadBazelJsonProtoFile,
+	FormatBazelXml:       loadBazelXmlFile,
+}
+
+// Formats lists all supported input formats
+var Formats = []string{
+	FormatJson,
+	FormatJsonLines,
+	FormatDot,
+	FormatCsv,
+	FormatTsv,
+	FormatGraphml,
+	FormatPants,
+	FormatBazelGraph,
+	FormatBazelProto,
+	FormatBazelJsonProto,
+	FormatBazelXml,
+}
+
+// formatExtensions is used to detect the input format when it's not set explicitly
+var formatExtensions = map[string]string{
+	".json":    FormatJson,
+	".jsonl":   FormatJsonLines,
+	".ndjson":  FormatJsonLines,
+	".dot":     FormatDot,
+	".gv":      FormatDot,
+	".csv":     FormatCsv,
+	".tsv":     FormatTsv,
+	".graphml": FormatGraphml,
+}
+
+// CompressionExtensions are extensions of compressed files which are ignored when detecting the input format
+var CompressionExtensions = []string{".gz", ".zst", ".zstd"}
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// IsValidFormat checks if the format is one of the supported input formats
+func IsValidFormat(format string) bool {
+	_, exists := loaders[format]
+	return exists
+}
+
+// Decompress returns data decompressed if it starts with gzip or zstd magic bytes and as is otherwise
+func Decompress(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, gzipMagic):
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		return io.ReadAll(reader)
+	case bytes.HasPrefix(data, zstdMagic):
+		decoder, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer decoder.Close()
+		return decoder.DecodeAll(data, nil)
+	}
+	return data, nil
+}
+
+/*
+FormatFromPath gets the input format from the file extension ignoring the extension
+of compressed files (e.g. `dg.dot.gz`); JSON is assumed for unknown extensions.
+*/
+func FormatFromPath(filePath string) string {
+	filePath = strings.ToLower(filePath)
+	for _, extension := range CompressionExtensions {
+		filePath = strings.TrimSuffix(filePath, extension)
+	}
+	if format, exists := formatExtensions[filepath.Ext(filePath)]; exists {
+		return format
+	}
+	return FormatJson
+}
+
+// withoutAttributes adapts a loader of a format that has no node attributes
+func withoutAttributes(load func(data []byte) (AdjacencyList, error)) LoaderFunc {
+	return func(data []byte) (AdjacencyList, NodeAttributes, error) {
+		adjacencyList, err := load(data)
+		return adjacencyList, nil, err
+	}
+}
+
+// ParseAdjacencyList parses (decompressed) file contents of given format keeping node attributes if the format provides them
+func ParseAdjacencyList(data []byte, format string) (AdjacencyList, NodeAttributes, error) {
+	load, exists := loaders[format]
+	if !exists {
+		return nil, nil, fmt.Errorf("invalid format: %s. Allowed formats are: %s", format, strings.Join(Formats, ","))
+	}
+	return load(data)
+}
+
+/*
+Parse (decompressed) file contents of given format and index the graph; the contents can also be
+an index written with EncodeIndex in which case the format is ignored and there are no node attributes.
+*/
+func Parse(data []byte, format string) (*Graph, NodeAttributes, error) {
+	if IsIndex(data) {
+		graph, err := DecodeIndex(data)
+		return graph, nil, err
+	}
+	adjacencyList, attributes, err := ParseAdjacencyList(data, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	return NewGraph(adjacencyList), attributes, nil
+}
+
+// Load reads a (possibly compressed) dependency graph file parsing it as the format matching the file extension
+func Load(filePath string) (*Graph, NodeAttributes, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err = Decompress(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	format := FormatFromPath(filePath)
+	graph, attributes, err := Parse(data, format)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading %s as %s: %w", filePath, format, err)
+	}
+	return graph, attributes, nil
+}
+
+func loadJsonFile(jsonData []byte) (AdjacencyList, error) {
+	var adjacencyList AdjacencyList
+	loadingJsonError := json.Unmarshal(jsonData, &adjacencyList)
+	if loadingJsonError != nil {
+		return nil, loadingJsonError
+	}
+	return adjacencyList, nil
+}
@@ -0,0 +1,29 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+/*
+DependencyCounts counts direct dependencies of every node (the reversed graph
+can be passed to count dependents as it has dependents as dependencies).
+*/
+func DependencyCounts(graph *Graph) map[string]int {
+	depsCount := make(map[string]int, graph.Size())
+	for node := range graph.Size() {
+		depsCount[graph.Name(node)] = len(graph.Dependencies(node))
+	}
+	return depsCount
+}
+
+/*
+TransitiveDependencyCounts counts transitive dependencies of every node (the reversed graph
+can be passed to count dependents as it has dependents as dependencies).
+*/
+func TransitiveDependencyCounts(graph *Graph) map[string]int {
+	depsCount := make(map[string]int, graph.Size())
+	search := graph.NewTraversal()
+	for node := range graph.Size() {
+		depsCount[graph.Name(node)] = len(search.Reachable([]int{node}, 0, nil))
+	}
+	return depsCount
+}
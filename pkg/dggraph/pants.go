@@ -0,0 +1,61 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const (
+	AttributeTargetType = "target_type"
+	AttributeSources    = "sources"
+)
+
+// pantsPeekTarget is a subset of fields of a target reported by the `pants peek` goal
+type pantsPeekTarget struct {
+	Address      string   `json:"address"`
+	TargetType   string   `json:"target_type"`
+	Dependencies []string `json:"dependencies"`
+	Sources      []string `json:"sources"`
+}
+
+/*
+Load the output of either `pants dependencies --format=json` (a mapping of a target to its dependencies)
+or `pants peek` (an array of targets with their fields). For the latter, target type and
+source files of every target are kept as node attributes.
+*/
+func loadPantsFile(data []byte) (AdjacencyList, NodeAttributes, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		adjacencyList, err := loadJsonFile(data)
+		return adjacencyList, nil, err
+	}
+
+	var targets []pantsPeekTarget
+	if err := json.Unmarshal(data, &targets); err != nil {
+		return nil, nil, err
+	}
+	adjacencyList := make(AdjacencyList)
+	attributes := make(NodeAttributes)
+	for _, target := range targets {
+		if target.Address == "" {
+			continue
+		}
+		adjacencyList.AddNode(target.Address)
+		for _, dep := range target.Dependencies {
+			adjacencyList.appendEdge(target.Address, dep)
+		}
+
+		targetAttributes := make(map[string]any)
+		if target.TargetType != "" {
+			targetAttributes[AttributeTargetType] = target.TargetType
+		}
+		if len(target.Sources) > 0 {
+			targetAttributes[AttributeSources] = target.Sources
+		}
+		attributes[target.Address] = targetAttributes
+	}
+	adjacencyList.dropDuplicateEdges()
+	return adjacencyList, attributes, nil
+}
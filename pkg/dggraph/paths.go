@@ -0,0 +1,72 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+/*
+Paths finds paths between nodes matching the from and to targets (which may be patterns);
+all paths are returned if the maximum number of paths is 0.
+*/
+func Paths(graph *Graph, fromTargets []string, toTargets []string, maxPaths int) ([][]string, error) {
+	fromTargets, err := ExpandPatterns(graph.Nodes(), fromTargets)
+	if err != nil {
+		return nil, err
+	}
+	toTargets, err = ExpandPatterns(graph.Nodes(), toTargets)
+	if err != nil {
+		return nil, err
+	}
+
+	// the maximum number of paths applies to all pairs of targets matching the patterns
+	var result [][]string
+	visited := make([]bool, graph.Size())
+	for _, from := range graph.ToIds(fromTargets) {
+		for _, to := range graph.ToIds(toTargets) {
+			dfsWithMemoization(graph, from, to, from, []int{}, &result, visited, maxPaths)
+		}
+	}
+
+	return result, nil
+}
+
+// dfs does a depth-first search (DFS) to find all (or some) paths from the "from" target to the "to" target
+func dfsWithMemoization(
+	graph *Graph,
+	currentNode,
+	endNode,
+	startNode int,
+	currentPath []int,
+	result *[][]string,
+	visited []bool,
+	maxPaths int,
+) {
+	if maxPaths > 0 && len(*result) >= maxPaths {
+		return
+	}
+
+	// Add the current node to the path
+	currentPath = append(currentPath, currentNode)
+
+	// If we reach the end node, add the current path to the result
+	if currentNode == endNode {
+		*result = append(*result, graph.ToNames(currentPath))
+		// Backtrack by removing the current node from the path
+		return
+	}
+
+	visited[currentNode] = true
+
+	// Continue exploring neighbors
+	for _, neighbor := range graph.Dependencies(currentNode) {
+		// Skip the neighbor if it has already been visited or if it is the start node
+		if visited[neighbor] || (neighbor == startNode && currentNode != startNode) {
+			continue
+		}
+
+		// Recursively visit neighbors
+		dfsWithMemoization(graph, neighbor, endNode, startNode, currentPath, result, visited, maxPaths)
+	}
+
+	// Backtrack: mark the current node as not visited for other paths
+	visited[currentNode] = false
+}
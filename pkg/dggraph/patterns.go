@@ -0,0 +1,160 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// prefix of the target patterns that are regular expressions
+const regexPatternPrefix = "re:"
+
+/*
+IsPattern checks if the target is a pattern rather than an exact node name. Supported patterns:
+  - regular expressions matching the whole node name, e.g. `re:.*_test\.py$`
+  - Bazel-style recursive patterns, e.g. `//lib/...` (or `//...` for all labels)
+  - Bazel-style package patterns, e.g. `//lib:all` or `//lib:*`
+  - Bazel-style recursive patterns with a glob of target names, e.g. `//...:*_test` or `//lib/...:db_*`
+  - globs where `*` and `?` do not match `/` but `**` does, e.g. `src/billing/**`
+
+A target that looks like a pattern is still taken as an exact node name if it's a node of the graph,
+e.g. `pages/[id].tsx` (see ExpandPatterns).
+*/
+func IsPattern(target string) bool {
+	return strings.HasPrefix(target, regexPatternPrefix) ||
+		target == "//..." || strings.HasSuffix(target, "/...") || strings.Contains(target, "/...:") ||
+		strings.HasSuffix(target, ":all") || strings.HasSuffix(target, ":*") ||
+		strings.ContainsAny(target, "*?[")
+}
+
+// compilePattern turns a target pattern into a function matching node names
+func compilePattern(pattern string) (func(node string) bool, error) {
+	switch {
+	case strings.HasPrefix(pattern, regexPatternPrefix):
+		expression, err := regexp.Compile("^(?:" + strings.TrimPrefix(pattern, regexPatternPrefix) + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		return expression.MatchString, nil
+	case pattern == "//...":
+		return func(node string) bool { return strings.HasPrefix(node, "//") }, nil
+	case strings.HasSuffix(pattern, "/..."):
+		pkg := strings.TrimSuffix(pattern, "/...")
+		return func(node string) bool {
+			return strings.HasPrefix(node, pkg+"/") || strings.HasPrefix(node, pkg+":") || node == pkg
+		}, nil
+	case strings.Contains(pattern, "/...:"):
+		// the package of a label is in the recursive package if it's the package itself or any package under it
+		pkg, name, _ := strings.Cut(pattern, "...:")
+		if name == "all" {
+			name = "*"
+		}
+		matchesName, err := compileGlob(pattern, name)
+		if err != nil {
+			return nil, err
+		}
+		return func(node string) bool {
+			nodePkg, nodeName, isLabel := strings.Cut(node, ":")
+			return isLabel && strings.HasPrefix(nodePkg+"/", pkg) && matchesName(nodeName)
+		}, nil
+	case strings.HasSuffix(pattern, ":all") || strings.HasSuffix(pattern, ":*"):
+		pkg, _, _ := strings.Cut(pattern, ":")
+		return func(node string) bool { return strings.HasPrefix(node, pkg+":") }, nil
+	}
+	return compileGlob(pattern, pattern)
+}
+
+// compileGlob translates a glob (which is the pattern or a part of it) into a function matching node names
+func compileGlob(pattern string, glob string) (func(node string) bool, error) {
+	var expression strings.Builder
+	expression.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expression.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expression.WriteString(".*")
+			i++
+		case c == '*':
+			expression.WriteString("[^/]*")
+		case c == '?':
+			expression.WriteString("[^/]")
+		case c == '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid pattern %q: unterminated character class", pattern)
+			}
+			expression.WriteString(glob[i : i+end+1])
+			i += end
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	expression.WriteString("$")
+	compiled, err := regexp.Compile(expression.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return compiled.MatchString, nil
+}
+
+/*
+ExpandPatterns expands target patterns into the matching nodes of the graph keeping the order of targets;
+exact node names are kept as is even if they are not in the graph, but a pattern
+that matches no nodes is an error. A target that is a node of the graph is never expanded
+even if it looks like a pattern.
+*/
+func ExpandPatterns(nodes []string, targets []string) ([]string, error) {
+	result := []string{}
+	for _, target := range targets {
+		if !IsPattern(target) || slices.Contains(nodes, target) {
+			result = append(result, target)
+			continue
+		}
+		matches, err := compilePattern(target)
+		if err != nil {
+			return nil, err
+		}
+		matched := false
+		for _, node := range nodes {
+			if matches(node) {
+				result = append(result, node)
+				matched = true
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("pattern %q does not match any node", target)
+		}
+	}
+	return result, nil
+}
+
+/*
+Get a function matching the nodes matched by any of target patterns or equal to any of the targets;
+a target that looks like a pattern also matches the node of the same name (see ExpandPatterns).
+*/
+func matchPatterns(targets []string) (func(node string) bool, error) {
+	exact := make(map[string]bool)
+	patterns := []func(node string) bool{}
+	for _, target := range targets {
+		exact[target] = true
+		if !IsPattern(target) {
+			continue
+		}
+		matches, err := compilePattern(target)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, matches)
+	}
+	return func(node string) bool {
+		return exact[node] || slices.ContainsFunc(patterns, func(matches func(node string) bool) bool {
+			return matches(node)
+		})
+	}, nil
+}
@@ -0,0 +1,125 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCasePatterns struct {
+	targets  []string
+	expected []string
+}
+
+var patternNodes = []string{
+	"//lib:lib",
+	"//lib/db:db",
+	"//lib/db:db_test",
+	"//libs:other",
+	"//third_party:json",
+	"src/billing/api.py",
+	"src/billing/internal/invoice.py",
+	"src/billing/internal/invoice_test.py",
+	"src/shipping/api.py",
+}
+
+func TestExpandPatterns(t *testing.T) {
+	cases := []testCasePatterns{
+		// exact names are kept even if not in the graph
+		{
+			targets:  []string{"src/shipping/api.py", "missing.py"},
+			expected: []string{"src/shipping/api.py", "missing.py"},
+		},
+		{
+			targets:  []string{"src/billing/**"},
+			expected: []string{"src/billing/api.py", "src/billing/internal/invoice.py", "src/billing/internal/invoice_test.py"},
+		},
+		{
+			targets:  []string{"src/*/api.py"},
+			expected: []string{"src/billing/api.py", "src/shipping/api.py"},
+		},
+		{
+			targets:  []string{"src/**/*_test.py"},
+			expected: []string{"src/billing/internal/invoice_test.py"},
+		},
+		{
+			targets:  []string{"src/billing/[ai]*.py"},
+			expected: []string{"src/billing/api.py"},
+		},
+		{
+			targets:  []string{"//lib/..."},
+			expected: []string{"//lib:lib", "//lib/db:db", "//lib/db:db_test"},
+		},
+		{
+			targets:  []string{"//lib/db:all", "//lib:*"},
+			expected: []string{"//lib/db:db", "//lib/db:db_test", "//lib:lib"},
+		},
+		{
+			targets:  []string{"//..."},
+			expected: []string{"//lib:lib", "//lib/db:db", "//lib/db:db_test", "//libs:other", "//third_party:json"},
+		},
+		// target names in recursive packages are matched by a glob
+		{
+			targets:  []string{"//...:*_test"},
+			expected: []string{"//lib/db:db_test"},
+		},
+		{
+			targets:  []string{"//lib/...:all"},
+			expected: []string{"//lib:lib", "//lib/db:db", "//lib/db:db_test"},
+		},
+		{
+			targets:  []string{"//lib/...:l*", "//...:json"},
+			expected: []string{"//lib:lib", "//third_party:json"},
+		},
+		// regular expressions match the whole node name
+		{
+			targets:  []string{`re:.*_test\.py`},
+			expected: []string{"src/billing/internal/invoice_test.py"},
+		},
+		{
+			targets:  []string{`re:.*_test`},
+			expected: []string{"//lib/db:db_test"},
+		},
+	}
+	for _, testCase := range cases {
+		result, err := ExpandPatterns(patternNodes, testCase.targets)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, testCase.expected, result, testCase.targets)
+	}
+}
+
+func TestExpandPatternsExactNodes(t *testing.T) {
+	nodes := []string{"pages/[id].tsx", "pages/i.tsx", "pages/index.tsx", "src/*.py"}
+
+	// nodes of the graph are taken by their names even if they look like patterns
+	result, err := ExpandPatterns(nodes, []string{"pages/[id].tsx", "src/*.py"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"pages/[id].tsx", "src/*.py"}, result)
+	result, err = ExpandPatterns(nodes, []string{"pages/[id].tsx", "pages/*.tsx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"pages/[id].tsx", "pages/[id].tsx", "pages/i.tsx", "pages/index.tsx"}, result)
+
+	matches, err := matchPatterns([]string{"pages/[id].tsx"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, matches("pages/[id].tsx"))
+	assert.True(t, matches("pages/i.tsx"))
+	assert.False(t, matches("pages/index.tsx"))
+}
+
+func TestExpandPatternsInvalid(t *testing.T) {
+	for _, pattern := range []string{"src/payments/**", "re:(", "src/[a.py", "//payments/...", "//lib/...:*_test.py", "//...:[a"} {
+		_, err := ExpandPatterns(patternNodes, []string{pattern})
+		assert.Error(t, err, pattern)
+	}
+}
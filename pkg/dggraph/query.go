@@ -0,0 +1,503 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/*
+Query evaluates an expression (similar to the Bazel query language) against the dependency graph
+returning the sorted set of nodes; given variables are bound (e.g. to results of previous queries). The supported expressions are:
+
+	word                      a node name or a target pattern (quoted with ' or " if it contains special characters)
+	$name                     a variable bound with `let`
+	deps(x), deps(x, depth)   x and its dependencies (up to given depth)
+	rdeps(u, x), rdeps(u, x, depth)
+	                          x and its dependents (up to given depth) within the set u
+	allpaths(a, b)            nodes on all paths from a to b
+	somepath(a, b)            nodes on one of the shortest paths from a to b
+	roots(x)                  nodes of x that no other node of x depends on
+	leaves(x)                 nodes of x that have no dependencies in x
+	x intersect y, x ^ y      nodes in both sets
+	x union y, x + y          nodes in either set
+	x except y, x - y         nodes in x but not in y
+	let v = x in y            evaluate y with $v bound to the value of x
+
+Binary operators are left-associative and have the same precedence, e.g. `a + b - c` is `(a + b) - c`.
+*/
+func Query(graph *Graph, expression string, variables map[string][]string) ([]string, error) {
+	parsed, err := parseQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	evaluator := &queryEvaluator{
+		graph:     graph,
+		reversed:  graph.Reversed(),
+		variables: make(map[string]nodeSet),
+	}
+	for name, nodes := range variables {
+		evaluator.variables[name] = newNodeSet(nodes...)
+	}
+	result, err := parsed.evaluate(evaluator)
+	if err != nil {
+		return nil, err
+	}
+	return result.sorted(), nil
+}
+
+// nodeSet is an unordered set of nodes
+type nodeSet map[string]bool
+
+func newNodeSet(nodes ...string) nodeSet {
+	set := make(nodeSet, len(nodes))
+	for _, node := range nodes {
+		set[node] = true
+	}
+	return set
+}
+
+func (set nodeSet) sorted() []string {
+	return sortedKeys(set)
+}
+
+type queryEvaluator struct {
+	graph     *Graph
+	reversed  *Graph
+	variables map[string]nodeSet
+}
+
+type queryExpression interface {
+	evaluate(evaluator *queryEvaluator) (nodeSet, error)
+}
+
+type queryWord struct {
+	name string
+}
+
+// words can also be target patterns such as `//lib/...` or `src/**/*.py`
+func (expression queryWord) evaluate(evaluator *queryEvaluator) (nodeSet, error) {
+	nodes, err := ExpandPatterns(evaluator.graph.Nodes(), []string{expression.name})
+	if err != nil {
+		return nil, err
+	}
+	return newNodeSet(nodes...), nil
+}
+
+type queryVariable struct {
+	name string
+}
+
+func (expression queryVariable) evaluate(evaluator *queryEvaluator) (nodeSet, error) {
+	value, exists := evaluator.variables[expression.name]
+	if !exists {
+		return nil, fmt.Errorf("undefined variable: $%s", expression.name)
+	}
+	return value, nil
+}
+
+type queryLet struct {
+	name  string
+	value queryExpression
+	body  queryExpression
+}
+
+func (expression queryLet) evaluate(evaluator *queryEvaluator) (nodeSet, error) {
+	value, err := expression.value.evaluate(evaluator)
+	if err != nil {
+		return nil, err
+	}
+	// restore the shadowed variable once the body is evaluated
+	previous, shadowed := evaluator.variables[expression.name]
+	evaluator.variables[expression.name] = value
+	defer func() {
+		if shadowed {
+			evaluator.variables[expression.name] = previous
+		} else {
+			delete(evaluator.variables, expression.name)
+		}
+	}()
+	return expression.body.evaluate(evaluator)
+}
+
+type queryBinary struct {
+	operator string
+	left     queryExpression
+	right    queryExpression
+}
+
+func (expression queryBinary) evaluate(evaluator *queryEvaluator) (nodeSet, error) {
+	left, err := expression.left.evaluate(evaluator)
+	if err != nil {
+		return nil, err
+	}
+	right, err := expression.right.evaluate(evaluator)
+	if err != nil {
+		return nil, err
+	}
+	result := make(nodeSet)
+	switch expression.operator {
+	case "intersect":
+		for node := range left {
+			if right[node] {
+				result[node] = true
+			}
+		}
+	case "union":
+		for node := range left {
+			result[node] = true
+		}
+		for node := range right {
+			result[node] = true
+		}
+	case "except":
+		for node := range left {
+			if !right[node] {
+				result[node] = true
+			}
+		}
+	}
+	return result, nil
+}
+
+type queryFunction struct {
+	name      string
+	arguments []queryExpression
+	// optional depth argument of `deps` and `rdeps`; -1 if not set
+	depth int
+}
+
+// number of set arguments of every query function
+var queryFunctions = map[string]int{
+	"deps":     1,
+	"rdeps":    2,
+	"allpaths": 2,
+	"somepath": 2,
+	"roots":    1,
+	"leaves":   1,
+}
+
+func (expression queryFunction) evaluate(evaluator *queryEvaluator) (nodeSet, error) {
+	arguments := []nodeSet{}
+	for _, argument := range expression.arguments {
+		value, err := argument.evaluate(evaluator)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, value)
+	}
+
+	switch expression.name {
+	case "deps":
+		return evaluator.reachable(evaluator.graph, arguments[0], expression.depth, nil), nil
+	case "rdeps":
+		// only dependents within the universe are considered
+		universe := arguments[0]
+		result := make(nodeSet)
+		for node := range evaluator.reachable(evaluator.reversed, arguments[1], expression.depth, universe) {
+			if universe[node] {
+				result[node] = true
+			}
+		}
+		return result, nil
+	case "allpaths":
+		forward := evaluator.reachable(evaluator.graph, arguments[0], -1, nil)
+		backward := evaluator.reachable(evaluator.reversed, arguments[1], -1, nil)
+		result := make(nodeSet)
+		for node := range forward {
+			if backward[node] {
+				result[node] = true
+			}
+		}
+		return result, nil
+	case "somepath":
+		return evaluator.somepath(arguments[0], arguments[1]), nil
+	case "roots", "leaves":
+		// roots have no dependents and leaves have no dependencies within the set
+		graph := evaluator.graph
+		if expression.name == "roots" {
+			graph = evaluator.reversed
+		}
+		result := make(nodeSet)
+		for node := range arguments[0] {
+			id, exists := graph.Lookup(node)
+			if !exists || !slices.ContainsFunc(graph.Dependencies(id), func(other int) bool { return arguments[0][graph.Name(other)] }) {
+				result[node] = true
+			}
+		}
+		return result, nil
+	}
+	return nil, fmt.Errorf("unknown function: %s", expression.name)
+}
+
+/*
+Get given nodes along with the nodes reachable from them up to given depth (-1 for no limit);
+if the universe is set, only the nodes within it are followed.
+*/
+func (evaluator *queryEvaluator) reachable(graph *Graph, nodes nodeSet, depth int, universe nodeSet) nodeSet {
+	result := make(nodeSet)
+	for node := range nodes {
+		result[node] = true
+	}
+	if depth == 0 {
+		return result
+	}
+	var accept func(id int) bool
+	if universe != nil {
+		accept = func(id int) bool { return universe[graph.Name(id)] }
+	}
+	// depth of 0 returns all transitive dependencies
+	for _, id := range graph.NewTraversal().Reachable(graph.ToIds(nodes.sorted()), max(depth, 0), accept) {
+		result[graph.Name(id)] = true
+	}
+	return result
+}
+
+// somepath finds one of the shortest paths from any node of one set to any node of another set
+func (evaluator *queryEvaluator) somepath(from nodeSet, to nodeSet) nodeSet {
+	graph := evaluator.graph
+	parents := make(map[int]int)
+	queue := graph.ToIds(from.sorted())
+	for _, node := range queue {
+		parents[node] = node
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		if to[graph.Name(node)] {
+			path := newNodeSet(graph.Name(node))
+			for parents[node] != node {
+				node = parents[node]
+				path[graph.Name(node)] = true
+			}
+			return path
+		}
+		for _, dep := range graph.Dependencies(node) {
+			if _, visited := parents[dep]; !visited {
+				parents[dep] = node
+				queue = append(queue, dep)
+			}
+		}
+	}
+	return make(nodeSet)
+}
+
+type queryToken struct {
+	value string
+	// quoted words are never treated as keywords or operators
+	quoted bool
+}
+
+func (token queryToken) is(value string) bool {
+	return !token.quoted && token.value == value
+}
+
+// tokenizeQuery splits a query expression into words, punctuation, and operators
+func tokenizeQuery(expression string) ([]queryToken, error) {
+	tokens := []queryToken{}
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case strings.ContainsRune("(),=^+-", rune(c)):
+			tokens = append(tokens, queryToken{value: string(c)})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quoted word at position %d", i)
+			}
+			tokens = append(tokens, queryToken{value: expression[i+1 : i+1+end], quoted: true})
+			i += end + 2
+		default:
+			// words may contain operator characters such as "-" (e.g. "foo-bar.py") but not punctuation
+			start := i
+			for i < len(expression) && !strings.ContainsRune(" \t\n\r(),=", rune(expression[i])) {
+				i++
+			}
+			tokens = append(tokens, queryToken{value: expression[start:i]})
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens   []queryToken
+	position int
+}
+
+func parseQuery(expression string) (queryExpression, error) {
+	tokens, err := tokenizeQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	parser := &queryParser{tokens: tokens}
+	parsed, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := parser.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q", token.value)
+	}
+	return parsed, nil
+}
+
+func (parser *queryParser) peek() (queryToken, bool) {
+	if parser.position >= len(parser.tokens) {
+		return queryToken{}, false
+	}
+	return parser.tokens[parser.position], true
+}
+
+func (parser *queryParser) next() (queryToken, error) {
+	token, ok := parser.peek()
+	if !ok {
+		return queryToken{}, fmt.Errorf("unexpected end of query")
+	}
+	parser.position++
+	return token, nil
+}
+
+func (parser *queryParser) expect(value string) error {
+	token, err := parser.next()
+	if err != nil {
+		return err
+	}
+	if !token.is(value) {
+		return fmt.Errorf("expected %q, got %q", value, token.value)
+	}
+	return nil
+}
+
+// binary operators with their symbolic aliases
+var queryOperators = map[string]string{
+	"intersect": "intersect",
+	"^":         "intersect",
+	"union":     "union",
+	"+":         "union",
+	"except":    "except",
+	"-":         "except",
+}
+
+func (parser *queryParser) parseExpression() (queryExpression, error) {
+	if token, ok := parser.peek(); ok && token.is("let") {
+		return parser.parseLet()
+	}
+	left, err := parser.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		token, ok := parser.peek()
+		if !ok || token.quoted {
+			return left, nil
+		}
+		operator, isOperator := queryOperators[token.value]
+		if !isOperator {
+			return left, nil
+		}
+		parser.position++
+		// `x + let v = ... in ...` is allowed as the right operand
+		var right queryExpression
+		if next, ok := parser.peek(); ok && next.is("let") {
+			right, err = parser.parseLet()
+		} else {
+			right, err = parser.parsePrimary()
+		}
+		if err != nil {
+			return nil, err
+		}
+		left = queryBinary{operator: operator, left: left, right: right}
+	}
+}
+
+func (parser *queryParser) parseLet() (queryExpression, error) {
+	parser.position++
+	name, err := parser.next()
+	if err != nil {
+		return nil, err
+	}
+	if name.quoted || strings.ContainsAny(name.value, "()=,$") {
+		return nil, fmt.Errorf("invalid variable name %q", name.value)
+	}
+	if err := parser.expect("="); err != nil {
+		return nil, err
+	}
+	value, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := parser.expect("in"); err != nil {
+		return nil, err
+	}
+	body, err := parser.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	return queryLet{name: name.value, value: value, body: body}, nil
+}
+
+func (parser *queryParser) parsePrimary() (queryExpression, error) {
+	token, err := parser.next()
+	if err != nil {
+		return nil, err
+	}
+	if token.quoted {
+		return queryWord{name: token.value}, nil
+	}
+	if token.is("(") {
+		inner, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		return inner, parser.expect(")")
+	}
+	if strings.HasPrefix(token.value, "$") && len(token.value) > 1 {
+		return queryVariable{name: token.value[1:]}, nil
+	}
+	if arity, isFunction := queryFunctions[token.value]; isFunction {
+		if next, ok := parser.peek(); ok && next.is("(") {
+			return parser.parseFunction(token.value, arity)
+		}
+	}
+	if len(token.value) == 1 && strings.ContainsAny(token.value, ")=,^+-") {
+		return nil, fmt.Errorf("unexpected %q", token.value)
+	}
+	return queryWord{name: token.value}, nil
+}
+
+func (parser *queryParser) parseFunction(name string, arity int) (queryExpression, error) {
+	parser.position++
+	function := queryFunction{name: name, depth: -1}
+	for i := 0; i < arity; i++ {
+		if i > 0 {
+			if err := parser.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		argument, err := parser.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		function.arguments = append(function.arguments, argument)
+	}
+	// `deps` and `rdeps` accept an optional depth as the last argument
+	if (name == "deps" || name == "rdeps") && parser.position < len(parser.tokens) && parser.tokens[parser.position].is(",") {
+		parser.position++
+		token, err := parser.next()
+		if err != nil {
+			return nil, err
+		}
+		depth, err := strconv.Atoi(token.value)
+		if err != nil || depth < 0 {
+			return nil, fmt.Errorf("invalid depth of %s: %q", name, token.value)
+		}
+		function.depth = depth
+	}
+	return function, parser.expect(")")
+}
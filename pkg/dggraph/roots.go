@@ -0,0 +1,16 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+// Roots returns nodes (aka sources) that no other node depends on sorted
+func Roots(graph *Graph) []string {
+	// nodes are sorted by their IDs
+	result := []string{}
+	for id := range graph.Size() {
+		if len(graph.Dependents(id)) == 0 {
+			result = append(result, graph.Name(id))
+		}
+	}
+	return result
+}
@@ -0,0 +1,190 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// types of dependency rules
+const (
+	// dependencies of the nodes of the `from` layers on the nodes of the `to` layers are forbidden
+	RuleForbidden = "forbidden"
+	// the nodes of the `from` layers may only depend on the nodes of the `to` layers
+	RuleAllowed = "allowed"
+	// the nodes of the `from` layers may have at most `max` direct dependents
+	RuleMaxFanIn = "max-fan-in"
+	// the nodes of the `from` layers may have at most `max` direct dependencies
+	RuleMaxFanOut = "max-fan-out"
+	// the nodes of the `from` layers may not depend on nodes they are dependencies of
+	RuleNoCycles = "no-cycles"
+	// the nodes of the `from` layers may have chains of at most `max` dependencies under them (see Heights)
+	RuleMaxDepth = "max-depth"
+)
+
+// RuleTypes are the supported types of dependency rules
+var RuleTypes = []string{RuleForbidden, RuleAllowed, RuleMaxFanIn, RuleMaxFanOut, RuleNoCycles, RuleMaxDepth}
+
+// Rules are dependency rules between layers of nodes defined with target patterns (see IsPattern)
+type Rules struct {
+	Layers map[string][]string `json:"layers"`
+	Rules  []Rule              `json:"rules"`
+}
+
+// Rule is a dependency rule applied to the nodes of the `from` layers (all nodes if there are none)
+type Rule struct {
+	// name of the rule reported with its violations (described by its type and layers by default)
+	Name string   `json:"name"`
+	Type string   `json:"type"`
+	From []string `json:"from"`
+	To   []string `json:"to"`
+	// maximum of the max-* rules which must be set (nil if it's missing)
+	Max *int `json:"max"`
+}
+
+// Violation is a dependency (or a node if To is empty) violating a rule
+type Violation struct {
+	Rule    string `json:"rule"`
+	From    string `json:"from"`
+	To      string `json:"to,omitempty"`
+	Message string `json:"message"`
+}
+
+// ParseRules parses a JSON rules file checking that rules are valid and only refer to defined layers
+func ParseRules(data []byte) (*Rules, error) {
+	var rules Rules
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("invalid rules: %w", err)
+	}
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		if !slices.Contains(RuleTypes, rule.Type) {
+			return nil, fmt.Errorf("invalid type of rule %d: %q. Allowed types are: %s", i+1, rule.Type, strings.Join(RuleTypes, ","))
+		}
+		for _, layer := range append(slices.Clone(rule.From), rule.To...) {
+			if _, exists := rules.Layers[layer]; !exists {
+				return nil, fmt.Errorf("rule %d refers to undefined layer %q", i+1, layer)
+			}
+		}
+		switch rule.Type {
+		case RuleForbidden, RuleAllowed:
+			if len(rule.To) == 0 {
+				return nil, fmt.Errorf("rule %d of type %s must have layers to depend on", i+1, rule.Type)
+			}
+		case RuleMaxFanIn, RuleMaxFanOut, RuleMaxDepth:
+			if rule.Max == nil {
+				return nil, fmt.Errorf("rule %d of type %s must have a maximum (max)", i+1, rule.Type)
+			}
+			if *rule.Max < 0 {
+				return nil, fmt.Errorf("rule %d of type %s must have a non-negative maximum", i+1, rule.Type)
+			}
+		}
+		if rule.Name == "" {
+			rule.Name = rule.describe()
+		}
+	}
+	return &rules, nil
+}
+
+// describe describes the rule by its type and layers, e.g. "forbidden lib -> app" or "max-fan-out 20"
+func (rule Rule) describe() string {
+	description := rule.Type
+	if len(rule.From) > 0 {
+		description += " " + strings.Join(rule.From, ",")
+	}
+	switch rule.Type {
+	case RuleForbidden, RuleAllowed:
+		description += " -> " + strings.Join(rule.To, ",")
+	case RuleMaxFanIn, RuleMaxFanOut, RuleMaxDepth:
+		description += fmt.Sprintf(" %d", *rule.Max)
+	}
+	return description
+}
+
+// inLayers gets a function checking if a node belongs to any of given layers (or to any node if there are none)
+func (rules *Rules) inLayers(layers []string) (func(node string) bool, error) {
+	if len(layers) == 0 {
+		return func(node string) bool { return true }, nil
+	}
+	patterns := []string{}
+	for _, layer := range layers {
+		patterns = append(patterns, rules.Layers[layer]...)
+	}
+	return matchPatterns(patterns)
+}
+
+/*
+Check checks the graph against the rules listing the violations of every rule in the order of the rules:
+dependencies violating a rule sorted by the node and then by the dependency, and nodes violating a rule sorted.
+*/
+func Check(graph *Graph, rules *Rules) ([]Violation, error) {
+	violations := []Violation{}
+	for _, rule := range rules.Rules {
+		from, err := rules.inLayers(rule.From)
+		if err != nil {
+			return nil, err
+		}
+		to, err := rules.inLayers(rule.To)
+		if err != nil {
+			return nil, err
+		}
+		start := len(violations)
+		var componentOf []int
+		var heights map[string]int
+		switch rule.Type {
+		case RuleNoCycles:
+			_, componentOf = strongComponents(graph)
+		case RuleMaxDepth:
+			heights = Heights(graph)
+		}
+
+		for node := range graph.Size() {
+			name := graph.Name(node)
+			if !from(name) {
+				continue
+			}
+			violation := Violation{Rule: rule.Name, From: name}
+			switch rule.Type {
+			case RuleForbidden, RuleAllowed, RuleNoCycles:
+				for _, dep := range graph.Dependencies(node) {
+					depName := graph.Name(dep)
+					violation.To = depName
+					switch {
+					case rule.Type == RuleForbidden && to(depName):
+						violation.Message = "dependency is forbidden"
+					case rule.Type == RuleAllowed && !to(depName):
+						violation.Message = "dependency is not allowed"
+					case rule.Type == RuleNoCycles && componentOf[node] == componentOf[dep]:
+						violation.Message = "dependency is part of a cycle"
+					default:
+						continue
+					}
+					violations = append(violations, violation)
+				}
+			case RuleMaxFanIn:
+				if fanIn := len(graph.Dependents(node)); fanIn > *rule.Max {
+					violation.Message = fmt.Sprintf("fan-in of %d exceeds %d", fanIn, *rule.Max)
+					violations = append(violations, violation)
+				}
+			case RuleMaxFanOut:
+				if fanOut := len(graph.Dependencies(node)); fanOut > *rule.Max {
+					violation.Message = fmt.Sprintf("fan-out of %d exceeds %d", fanOut, *rule.Max)
+					violations = append(violations, violation)
+				}
+			case RuleMaxDepth:
+				if depth := heights[name]; depth > *rule.Max {
+					violation.Message = fmt.Sprintf("depth of %d exceeds %d", depth, *rule.Max)
+					violations = append(violations, violation)
+				}
+			}
+		}
+		slices.SortFunc(violations[start:], func(a Violation, b Violation) int {
+			return compareEdges(Edge{From: a.From, To: a.To}, Edge{From: b.From, To: b.To})
+		})
+	}
+	return violations, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheck(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"src/app/main.py":   {"src/lib/db.py", "src/shared/log.py"},
+		"src/lib/db.py":     {"src/app/config.py", "src/shared/log.py", "vendor/orm.py"},
+		"src/lib/cache.py":  {"src/lib/db.py"},
+		"src/app/config.py": {"src/lib/cache.py"},
+	})
+	rules, err := ParseRules([]byte(`{
+		"layers": {
+			"app": ["src/app/**"],
+			"lib": ["src/lib/**"],
+			"shared": ["src/shared/**"]
+		},
+		"rules": [
+			{"name": "lib must not depend on app", "type": "forbidden", "from": ["lib"], "to": ["app"]},
+			{"type": "allowed", "from": ["lib"], "to": ["lib", "shared"]},
+			{"type": "max-fan-in", "from": ["shared"], "max": 1},
+			{"type": "max-fan-out", "max": 2},
+			{"type": "no-cycles", "from": ["app"]},
+			{"type": "max-depth", "from": ["app"], "max": 1}
+		]
+	}`))
+	assert.NoError(t, err)
+	violations, err := Check(graph, rules)
+	assert.NoError(t, err)
+	assert.Equal(t, []Violation{
+		{Rule: "lib must not depend on app", From: "src/lib/db.py", To: "src/app/config.py", Message: "dependency is forbidden"},
+		{Rule: "allowed lib -> lib,shared", From: "src/lib/db.py", To: "src/app/config.py", Message: "dependency is not allowed"},
+		{Rule: "allowed lib -> lib,shared", From: "src/lib/db.py", To: "vendor/orm.py", Message: "dependency is not allowed"},
+		{Rule: "max-fan-in shared 1", From: "src/shared/log.py", Message: "fan-in of 2 exceeds 1"},
+		{Rule: "max-fan-out 2", From: "src/lib/db.py", Message: "fan-out of 3 exceeds 2"},
+		{Rule: "no-cycles app", From: "src/app/config.py", To: "src/lib/cache.py", Message: "dependency is part of a cycle"},
+		{Rule: "max-depth app 1", From: "src/app/main.py", Message: "depth of 2 exceeds 1"},
+	}, violations)
+}
+
+func TestParseRulesErrors(t *testing.T) {
+	_, err := ParseRules([]byte(`{"rules": [{"type": "forbidden", "from": ["lib"], "to": ["app"]}]}`))
+	assert.EqualError(t, err, `rule 1 refers to undefined layer "lib"`)
+	_, err = ParseRules([]byte(`{"layers": {"lib": ["lib/**"]}, "rules": [{"type": "allowed", "from": ["lib"]}]}`))
+	assert.EqualError(t, err, "rule 1 of type allowed must have layers to depend on")
+	_, err = ParseRules([]byte(`{"rules": [{"type": "max-fan-out", "max": -1}]}`))
+	assert.EqualError(t, err, "rule 1 of type max-fan-out must have a non-negative maximum")
+	_, err = ParseRules([]byte(`{"rules": [{"type": "no-cycles"}, {"type": "max-depth"}]}`))
+	assert.EqualError(t, err, "rule 2 of type max-depth must have a maximum (max)")
+	_, err = ParseRules([]byte(`{"rules": [{"type": "max-fan-in", "max": 0}]}`))
+	assert.NoError(t, err)
+	_, err = ParseRules([]byte(`{"rules": [{"type": "layering"}]}`))
+	assert.EqualError(t, err, `invalid type of rule 1: "layering". Allowed types are: forbidden,allowed,max-fan-in,max-fan-out,no-cycles,max-depth`)
+	_, err = ParseRules([]byte(`[]`))
+	assert.ErrorContains(t, err, "invalid rules")
+}
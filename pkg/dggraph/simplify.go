@@ -0,0 +1,45 @@
+/*
+Copyright © 2025 Alexey Tereshenkov
+*/
+package dggraph
+
+// TransitiveReduction removes dependencies of nodes that are also transitive dependencies of other direct dependencies
+func TransitiveReduction(graph *Graph) AdjacencyList {
+	result := make(AdjacencyList)
+	search := graph.NewTraversal()
+
+	for node := range graph.Size() {
+		if !graph.IsDeclared(node) {
+			continue
+		}
+		directDeps := graph.Dependencies(node)
+
+		// direct dependencies that can be reached through any other direct dependency
+		redundant := make(map[int]bool)
+		if len(directDeps) > 1 {
+			isDirect := make(map[int]bool, len(directDeps))
+			for _, dep := range directDeps {
+				isDirect[dep] = true
+			}
+			for _, otherDep := range directDeps {
+				// depth of 0 returns all transitive dependencies
+				for _, dep := range search.Reachable([]int{otherDep}, 0, nil) {
+					if dep != otherDep && isDirect[dep] {
+						redundant[dep] = true
+					}
+				}
+			}
+		}
+
+		// keep the dependency only if it's not a transitive dependency through any other direct dependency
+		newDeps := []string{}
+		for _, dep := range directDeps {
+			if !redundant[dep] {
+				newDeps = append(newDeps, graph.Name(dep))
+			}
+		}
+		result[graph.Name(node)] = newDeps
+	}
+
+	return result
+}
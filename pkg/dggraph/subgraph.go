@@ -0,0 +1,38 @@
+/*
+Copyright © 2025 Alexey Tereshenkov
+*/
+package dggraph
+
+/*
+Subgraph returns a new subgraph as adjacency list containing only the nodes reachable from the given root nodes
+(or from all nodes matching the root node patterns).
+*/
+func Subgraph(graph *Graph, rootNodes []string) (AdjacencyList, error) {
+	rootNodes, err := ExpandPatterns(graph.Nodes(), rootNodes)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(AdjacencyList)
+	visited := make([]bool, graph.Size())
+
+	var dfs func(node int)
+	dfs = func(node int) {
+		// only the nodes that are keys of the adjacency list are part of the subgraph
+		if visited[node] || !graph.IsDeclared(node) {
+			return
+		}
+
+		visited[node] = true
+		result[graph.Name(node)] = graph.ToNames(graph.Dependencies(node))
+
+		for _, neighbor := range graph.Dependencies(node) {
+			dfs(neighbor)
+		}
+	}
+
+	for _, node := range graph.ToIds(rootNodes) {
+		dfs(node)
+	}
+	return result, nil
+}
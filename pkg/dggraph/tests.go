@@ -0,0 +1,82 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"slices"
+)
+
+// TestSelector selects the nodes that are tests by their names and attributes
+type TestSelector struct {
+	// target patterns (see IsPattern) or names of tests; not checked if empty
+	Patterns []string
+	// attribute of tests, e.g. the target type; not checked if empty
+	Attribute string
+	// value of the attribute of tests; a list attribute must contain the value
+	Value string
+}
+
+// selects gets a function checking if a node is a test, i.e. it matches all the criteria of the selector
+func (selector TestSelector) selects(attributes NodeAttributes) (func(node string) bool, error) {
+	matches := func(node string) bool { return true }
+	if len(selector.Patterns) > 0 {
+		var err error
+		if matches, err = matchPatterns(selector.Patterns); err != nil {
+			return nil, err
+		}
+	}
+	return func(node string) bool {
+		if !matches(node) {
+			return false
+		}
+		if selector.Attribute == "" {
+			return true
+		}
+		switch value := attributes[node][selector.Attribute].(type) {
+		case []string:
+			return slices.Contains(value, selector.Value)
+		case []any:
+			return slices.ContainsFunc(value, func(item any) bool { return fmt.Sprint(item) == selector.Value })
+		case nil:
+			return false
+		default:
+			return fmt.Sprint(value) == selector.Value
+		}
+	}, nil
+}
+
+/*
+TestsFor gets the tests affected by changes of given nodes, i.e. the affected nodes (see Affected) selected
+as tests, with the reason they're affected. The tests are sorted by name or, with byDistance, by the distance
+from the changes (the closest first) and then by name. The graph must be the reverse dependency graph and
+the attributes are the ones of its nodes (if tests are selected by an attribute). A pattern or name of tests
+that matches no node of the graph is an error as it would silently select no tests.
+*/
+func TestsFor(reversed *Graph, changed []string, selector TestSelector, attributes NodeAttributes,
+	byDistance bool) ([]AffectedNode, error) {
+	for _, pattern := range selector.Patterns {
+		matches, err := matchPatterns([]string{pattern})
+		if err != nil {
+			return nil, err
+		}
+		if !slices.ContainsFunc(reversed.Nodes(), matches) {
+			return nil, fmt.Errorf("test pattern %q does not match any node", pattern)
+		}
+	}
+	isTest, err := selector.selects(attributes)
+	if err != nil {
+		return nil, err
+	}
+	tests := slices.DeleteFunc(Affected(reversed, changed), func(node AffectedNode) bool {
+		return !isTest(node.Node)
+	})
+	if byDistance {
+		// the sort is stable, so tests at the same distance stay sorted by name
+		slices.SortStableFunc(tests, func(a AffectedNode, b AffectedNode) int {
+			return len(a.Reason) - len(b.Reason)
+		})
+	}
+	return tests, nil
+}
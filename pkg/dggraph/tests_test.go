@@ -0,0 +1,78 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTestsFor(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"app/main.py":      {"lib/db.py"},
+		"app/main_test.py": {"app/main.py"},
+		"lib/db.py":        {"lib/log.py"},
+		"lib/db_test.py":   {"lib/db.py"},
+		"lib/log_test.py":  {"lib/log.py"},
+	})
+	reversed := graph.Reversed()
+
+	tests, err := TestsFor(reversed, []string{"lib/log.py"}, TestSelector{Patterns: []string{"re:.*_test\\.py"}}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []AffectedNode{
+		{Node: "app/main_test.py", Reason: []string{"lib/log.py", "lib/db.py", "app/main.py", "app/main_test.py"}},
+		{Node: "lib/db_test.py", Reason: []string{"lib/log.py", "lib/db.py", "lib/db_test.py"}},
+		{Node: "lib/log_test.py", Reason: []string{"lib/log.py", "lib/log_test.py"}},
+	}, tests)
+
+	// the closest tests come first
+	tests, err = TestsFor(reversed, []string{"lib/log.py"}, TestSelector{Patterns: []string{"**/*_test.py"}}, nil, true)
+	assert.NoError(t, err)
+	assert.Equal(t, "lib/log_test.py", tests[0].Node)
+	assert.Equal(t, "lib/db_test.py", tests[1].Node)
+	assert.Equal(t, "app/main_test.py", tests[2].Node)
+
+	// tests selected by an attribute and a pattern
+	attributes := NodeAttributes{
+		"app/main_test.py": {AttributeTargetType: "python_tests"},
+		"lib/db_test.py":   {AttributeTargetType: "python_tests"},
+		"lib/log_test.py":  {AttributeKind: []any{"py_test", "flaky"}},
+	}
+	selector := TestSelector{Patterns: []string{"lib/**"}, Attribute: AttributeTargetType, Value: "python_tests"}
+	tests, err = TestsFor(reversed, []string{"lib/log.py"}, selector, attributes, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []AffectedNode{{Node: "lib/db_test.py", Reason: []string{"lib/log.py", "lib/db.py", "lib/db_test.py"}}}, tests)
+	tests, err = TestsFor(reversed, []string{"lib/log.py"}, TestSelector{Attribute: AttributeKind, Value: "py_test"}, attributes, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []AffectedNode{{Node: "lib/log_test.py", Reason: []string{"lib/log.py", "lib/log_test.py"}}}, tests)
+
+	_, err = TestsFor(reversed, []string{"lib/log.py"}, TestSelector{Patterns: []string{"re:("}}, nil, false)
+	assert.ErrorContains(t, err, "invalid pattern")
+	// patterns of tests matching no node are most likely a mistake
+	_, err = TestsFor(reversed, []string{"lib/log.py"}, TestSelector{Patterns: []string{"re:.*_test\\.py", "//...:*_test"}}, nil, false)
+	assert.EqualError(t, err, `test pattern "//...:*_test" does not match any node`)
+}
+
+func TestTestsForBazel(t *testing.T) {
+	reversed := NewGraph(AdjacencyList{
+		"//app:main":       {"//lib/db:db"},
+		"//app:main_test":  {"//app:main"},
+		"//lib/db:db":      {"//lib/log:log"},
+		"//lib/db:db_test": {"//lib/db:db"},
+		"//lib/log:log":    {},
+	}).Reversed()
+
+	// test targets are selected in all packages by the glob of their names
+	tests, err := TestsFor(reversed, []string{"//lib/log:log"}, TestSelector{Patterns: []string{"//...:*_test"}}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, []AffectedNode{
+		{Node: "//app:main_test", Reason: []string{"//lib/log:log", "//lib/db:db", "//app:main", "//app:main_test"}},
+		{Node: "//lib/db:db_test", Reason: []string{"//lib/log:log", "//lib/db:db", "//lib/db:db_test"}},
+	}, tests)
+	tests, err = TestsFor(reversed, []string{"//lib/log:log"}, TestSelector{Patterns: []string{"//lib/...:*_test"}}, nil, false)
+	assert.NoError(t, err)
+	assert.Equal(t, "//lib/db:db_test", tests[0].Node)
+	assert.Len(t, tests, 1)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CycleError is returned when nodes cannot be ordered as the graph has cycles
+type CycleError struct {
+	// strongly connected components with cycles, the largest first
+	Components [][]string
+}
+
+func (err *CycleError) Error() string {
+	components := make([]string, len(err.Components))
+	for i, component := range err.Components {
+		components[i] = "[" + strings.Join(component, " ") + "]"
+	}
+	return fmt.Sprintf("dependency graph has cycles in strongly connected components: %s", strings.Join(components, ", "))
+}
+
+/*
+Layers groups nodes into levels that can be built in parallel: the first layer has the leaves and every
+next layer has the nodes whose dependencies are all in the previous layers, so that every node is in the layer
+right after its deepest dependency. Nodes of every layer are sorted. A CycleError listing the strongly
+connected components with cycles is returned if the graph has cycles.
+*/
+func Layers(graph *Graph) ([][]string, error) {
+	// number of dependencies of every node not in a layer yet
+	remaining := make([]int, graph.Size())
+	layer := []int{}
+	for id := range graph.Size() {
+		remaining[id] = len(graph.Dependencies(id))
+		if remaining[id] == 0 {
+			layer = append(layer, id)
+		}
+	}
+	layers := [][]string{}
+	placed := 0
+	for len(layer) > 0 {
+		layers = append(layers, graph.ToNames(layer))
+		placed += len(layer)
+		next := []int{}
+		for _, node := range layer {
+			for _, dependent := range graph.Dependents(node) {
+				remaining[dependent]--
+				if remaining[dependent] == 0 {
+					next = append(next, dependent)
+				}
+			}
+		}
+		slices.Sort(next)
+		layer = next
+	}
+	if placed < graph.Size() {
+		return nil, &CycleError{Components: cyclicComponents(graph)}
+	}
+	return layers, nil
+}
+
+/*
+TopologicalSort orders nodes so that every node comes after all of its dependencies (i.e. in a build order),
+which are the layers of the graph one after another. A CycleError listing the strongly connected components
+with cycles is returned if the graph has cycles.
+*/
+func TopologicalSort(graph *Graph) ([]string, error) {
+	layers, err := Layers(graph)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, graph.Size())
+	for _, layer := range layers {
+		result = append(result, layer...)
+	}
+	return result, nil
+}
+
+// cyclicComponents gets strongly connected components with more than one node or with a self-loop
+func cyclicComponents(graph *Graph) [][]string {
+	result := [][]string{}
+	for _, component := range StronglyConnectedComponents(graph) {
+		if len(component) > 1 {
+			result = append(result, component)
+			continue
+		}
+		id, _ := graph.Lookup(component[0])
+		if slices.Contains(graph.Dependencies(id), id) {
+			result = append(result, component)
+		}
+	}
+	return result
+}
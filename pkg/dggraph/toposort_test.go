@@ -0,0 +1,44 @@
+/*
+Copyright © 2024 Alexey Tereshenkov
+*/
+package dggraph
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayers(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"app/cli.py":  {"lib/log.py"},
+		"app/main.py": {"lib/db.py", "lib/log.py"},
+		"lib/db.py":   {"lib/log.py"},
+	})
+	layers, err := Layers(graph)
+	assert.NoError(t, err)
+	// the command line tool only depends on the leaf, so it's in the second layer
+	assert.Equal(t, [][]string{{"lib/log.py"}, {"app/cli.py", "lib/db.py"}, {"app/main.py"}}, layers)
+
+	order, err := TopologicalSort(graph)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"lib/log.py", "app/cli.py", "lib/db.py", "app/main.py"}, order)
+
+	layers, err = Layers(NewGraph(AdjacencyList{}))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{}, layers)
+}
+
+func TestLayersCycles(t *testing.T) {
+	graph := NewGraph(AdjacencyList{
+		"a": {"b", "log"},
+		"b": {"a"},
+		"c": {"c"},
+		"d": {"a"},
+	})
+	_, err := TopologicalSort(graph)
+	var cycleError *CycleError
+	assert.ErrorAs(t, err, &cycleError)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, cycleError.Components)
+	assert.EqualError(t, err, "dependency graph has cycles in strongly connected components: [a b], [c]")
+}
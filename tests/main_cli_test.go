@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"encoding/json"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -40,6 +41,49 @@ func TestCliDependents(t *testing.T) {
 	buf.Reset()
 }
 
+func TestCliAffected(t *testing.T) {
+
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+
+	cmd.RootCmd.SetArgs([]string{"affected", "--dg=examples/dg.json", "--changed-files=foo-dep1-dep1.py,README.md"})
+	cmd.RootCmd.Execute()
+
+	expected := []string{"foo-dep1-dep1.py", "foo-dep1.py", "foo.py"}
+	actualOutput := strings.Split(buf.String(), "\n")[:len(expected)]
+	assert.Equal(t, expected, actualOutput)
+	buf.Reset()
+}
+
+func TestCliTestsFor(t *testing.T) {
+
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+
+	cmd.RootCmd.SetArgs([]string{"tests-for", "--dg=examples/dg.json", "--test-pattern=foo.py", "--by-distance", "foo-dep1-dep1.py"})
+	cmd.RootCmd.Execute()
+
+	expected := []string{"foo.py", ""}
+	actualOutput := strings.Split(buf.String(), "\n")
+	assert.Equal(t, expected, actualOutput)
+	buf.Reset()
+}
+
+func TestCliDiff(t *testing.T) {
+
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+
+	cmd.RootCmd.SetArgs([]string{"diff", "--old=examples/dg.json", "--new=examples/dg.json"})
+	cmd.RootCmd.Execute()
+
+	assert.Equal(t, "No changes\n", buf.String())
+	buf.Reset()
+}
+
 func TestCliRoots(t *testing.T) {
 
 	var buf bytes.Buffer
@@ -106,6 +150,11 @@ func TestCliCycles(t *testing.T) {
 	json.Unmarshal(expected, &expectedOutput)
 	assert.Equal(t, expectedOutput, actualOutput)
 	buf.Reset()
+
+	cmd.RootCmd.SetArgs([]string{"cycles", "--dg=examples/dg.json", "--through=foo.py", "--max-length=3", "--max-cycles=10", "--timeout=10s"})
+	cmd.RootCmd.Execute()
+	assert.Equal(t, "[]\n", buf.String())
+	buf.Reset()
 }
 
 func TestCliSubgraph(t *testing.T) {
@@ -165,6 +214,45 @@ func TestCliComponents(t *testing.T) {
 	buf.Reset()
 }
 
+func TestCliComponentsStrong(t *testing.T) {
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+	// persistent flags keep their values between executions of the root command
+	defer cmd.RootCmd.PersistentFlags().Set("output", "")
+
+	// the graph is acyclic, so every node is a strongly connected component on its own
+	cmd.RootCmd.SetArgs([]string{"components", "--strong", "--min-size=1", "--output=csv", "--dg=examples/dg.json"})
+	cmd.RootCmd.Execute()
+	assert.Equal(t, 10, strings.Count(buf.String(), "\n1,"))
+	buf.Reset()
+
+	cmd.RootCmd.SetArgs([]string{"components", "--strong", "--min-size=2", "--output=json", "--dg=examples/dg.json"})
+	cmd.RootCmd.Execute()
+	assert.Equal(t, "[]\n", buf.String())
+	buf.Reset()
+}
+
+func TestCliCondense(t *testing.T) {
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+
+	// the condensation of an acyclic graph is the graph itself
+	cmd.RootCmd.SetArgs([]string{"condense", "--dg=examples/dg.json"})
+	cmd.RootCmd.Execute()
+
+	var actualOutput cmd.AdjacencyList
+	json.Unmarshal(buf.Bytes(), &actualOutput)
+	assert.Equal(t, cmd.AdjacencyList{
+		"foo.py":       {"foo-dep1.py", "foo-dep2.py"},
+		"spam.py":      {"spam-dep1.py", "spam-dep2.py"},
+		"foo-dep1.py":  {"foo-dep1-dep1.py", "foo-dep1-dep2.py"},
+		"spam-dep2.py": {"spam-dep2-dep1.py", "spam-dep2-dep2.py"},
+	}, actualOutput)
+	buf.Reset()
+}
+
 func TestCliMetrics(t *testing.T) {
 
 	var buf bytes.Buffer
@@ -239,3 +327,65 @@ func TestCliMetrics(t *testing.T) {
 	assert.Equal(t, expectedOutput, actualOutput)
 	buf.Reset()
 }
+
+func TestCliOutput(t *testing.T) {
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+	// persistent flags keep their values between executions of the root command
+	defer cmd.RootCmd.PersistentFlags().Set("output", "")
+
+	cmd.RootCmd.SetArgs([]string{"roots", "--dg=examples/dg.json", "--output=json"})
+	cmd.RootCmd.Execute()
+
+	var actualOutput []string
+	json.Unmarshal(buf.Bytes(), &actualOutput)
+	assert.Equal(t, []string{"foo.py", "spam.py"}, actualOutput)
+	buf.Reset()
+
+	cmd.RootCmd.SetArgs([]string{"paths", "--dg=examples/dg.json", "--from=foo.py", "--to=foo-dep1-dep1.py", "--output=text"})
+	cmd.RootCmd.Execute()
+
+	assert.Equal(t, "foo.py foo-dep1.py foo-dep1-dep1.py\n", buf.String())
+	buf.Reset()
+}
+
+func TestCliIndex(t *testing.T) {
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+
+	indexPath := filepath.Join(t.TempDir(), "dg.idx")
+	cmd.RootCmd.SetArgs([]string{"index", "--dg=examples/dg.json", "--out=" + indexPath})
+	cmd.RootCmd.Execute()
+	assert.Equal(t, indexPath+"\n", buf.String())
+	buf.Reset()
+
+	cmd.RootCmd.SetArgs([]string{"dependencies", "--transitive", "--reflexive=false", "--dg=" + indexPath, "foo.py"})
+	cmd.RootCmd.Execute()
+	assert.Equal(t, "foo-dep1-dep1.py\nfoo-dep1-dep2.py\nfoo-dep1.py\nfoo-dep2.py\n", buf.String())
+	buf.Reset()
+}
+
+func TestCliToposort(t *testing.T) {
+	var buf bytes.Buffer
+	cmd.RootCmd.SetOut(&buf)
+	cmd.RootCmd.SetErr(&buf)
+
+	cmd.RootCmd.SetArgs([]string{"toposort", "--layers", "--dg=examples/dg.json"})
+	cmd.RootCmd.Execute()
+
+	var actualOutput [][]string
+	json.Unmarshal(buf.Bytes(), &actualOutput)
+	assert.Equal(t, [][]string{
+		{"foo-dep1-dep1.py", "foo-dep1-dep2.py", "foo-dep2.py", "spam-dep1.py", "spam-dep2-dep1.py", "spam-dep2-dep2.py"},
+		{"foo-dep1.py", "spam-dep2.py"},
+		{"foo.py", "spam.py"},
+	}, actualOutput)
+	buf.Reset()
+
+	cmd.RootCmd.SetArgs([]string{"toposort", "--layers=false", "--dg=examples/dg.json"})
+	cmd.RootCmd.Execute()
+	assert.True(t, strings.HasSuffix(buf.String(), "foo-dep1.py\nspam-dep2.py\nfoo.py\nspam.py\n"))
+	buf.Reset()
+}
@@ -174,6 +174,34 @@ func TestDependentsCommandPerfDeepGraph(t *testing.T) {
 
 }
 
+/*
+Testing performance of getting dependents for a node in a
+wide graph where all nodes depend on a single node, i.e. {1: [0], 2: [0], 3: [0]..., N: [0]}
+*/
+func TestDependentsCommandPerfWideGraph(t *testing.T) {
+	startTime := time.Now()
+
+	nodesCount := 100000
+	MockReadFile := func(filePath string) ([]byte, error) {
+		graph := make(map[string][]string)
+		for i := 1; i <= nodesCount; i++ {
+			graph[cast.ToString(i)] = []string{"0"}
+		}
+		lists, _ := json.Marshal(graph)
+		return lists, nil
+	}
+	result, err := cmd.Dependents("mock-dg.json", "", []string{"0"}, false, false, 0, MockReadFile)
+	if err != nil {
+		t.Fail()
+	}
+	assert.Equal(t, nodesCount, len(result), "Failing assertion")
+
+	elapsedTime := time.Since(startTime)
+	if elapsedTime.Seconds() > 2 {
+		t.Fatalf("Getting dependents out of a wide graph took too long: %s.", elapsedTime)
+	}
+}
+
 /*
 Testing performance of counting dependencies for all nodes in a
 deeply nested graph, i.e. {1: [2], 2: [3], 3: [4]..., N: [N+1]}.